@@ -2,12 +2,51 @@
 
 cd "$(dirname "$0")"
 
-rm weather-script-output.png
-eips -c
-eips -c
+url=http://server/path/to/weather-script-output.png
+image=weather-script-output.png
+etag=weather-script-output.etag
 
-if wget http://server/path/to/weather-script-output.png; then
-	eips -g weather-script-output.png
+show() {
+	eips -c
+	eips -c
+	eips -g "$1"
+}
+
+# The server answers 503 with an image explaining the problem when it cannot
+# produce a forecast. curl keeps that image; wget discards it, so without curl
+# the generic error image is shown instead.
+#
+# With curl the image's ETag is kept and sent back, so while the image has not
+# changed the server answers 304 and the screen is left alone.
+if command -v curl >/dev/null 2>&1; then
+	set -- -s -o "$image.new" -D "$image.headers" -w '%{http_code}'
+	if [ -f "$image" ] && [ -f "$etag" ]; then
+		set -- "$@" -H "If-None-Match: $(cat "$etag")"
+	fi
+	status=$(curl "$@" "$url")
+	case "$status" in
+	304) ;;
+	200)
+		mv "$image.new" "$image"
+		sed -n 's/^[Ee][Tt][Aa][Gg]: *//p' "$image.headers" | tr -d '\r' >"$etag"
+		show "$image"
+		;;
+	503)
+		rm -f "$etag"
+		mv "$image.new" "$image"
+		show "$image"
+		;;
+	*)
+		rm -f "$etag"
+		show weather-image-error.png
+		;;
+	esac
+	rm -f "$image.new" "$image.headers"
 else
-	eips -g weather-image-error.png
+	rm -f "$image"
+	if wget "$url"; then
+		show "$image"
+	else
+		show weather-image-error.png
+	fi
 fi
@@ -0,0 +1,62 @@
+package main
+
+import "math"
+
+// aqiBreakpoints are the upper bounds of the EPA air quality categories,
+// from good to very unhealthy; anything above is hazardous. Locales name
+// the categories in AQICategories.
+var aqiBreakpoints = []int{50, 100, 150, 200, 300}
+
+// aqiCategory returns the index of the EPA category of an AQI.
+func aqiCategory(aqi int) int {
+	for i, max := range aqiBreakpoints {
+		if aqi <= max {
+			return i
+		}
+	}
+	return len(aqiBreakpoints)
+}
+
+// pollutants are the pollutants of the EPA index in the order Tomorrow.io
+// numbers them.
+var pollutants = []string{"pm25", "pm10", "o3", "no2", "co", "so2"}
+
+// pollutantNames are the display names of AirQuality.PrimaryPollutant.
+var pollutantNames = map[string]string{
+	"pm25": "PM2.5",
+	"pm10": "PM10",
+	"o3":   "O₃",
+	"no2":  "NO₂",
+	"co":   "CO",
+	"so2":  "SO₂",
+}
+
+func pollutantName(id string) string {
+	if name, ok := pollutantNames[id]; ok {
+		return name
+	}
+	return id
+}
+
+// The pollen counts in grains/m³ at which a pollen type reaches low,
+// medium, high and very high levels, after the US National Allergy Bureau.
+var (
+	treePollenLevels  = [4]float64{1, 15, 90, 1500}
+	grassPollenLevels = [4]float64{1, 5, 20, 200}
+	weedPollenLevels  = [4]float64{1, 10, 50, 500}
+)
+
+// pollenLevel maps a pollen count onto the 0 to 5 scale of Pollen; any
+// pollen below the low threshold is very low.
+func pollenLevel(count float64, thresholds [4]float64) int {
+	if count <= 0 || math.IsNaN(count) {
+		return 0
+	}
+	level := 1
+	for _, t := range thresholds {
+		if count >= t {
+			level++
+		}
+	}
+	return level
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Alert is a weather warning in effect for a location.
+type Alert struct {
+	Event    string
+	Severity string
+	Headline string
+	Onset    time.Time
+	// Expires is zero when the issuer gave no end time.
+	Expires time.Time
+	Source  string
+}
+
+// AlertSource fetches the alerts in effect for a location.
+type AlertSource interface {
+	Name() string
+	Alerts(loc Location) ([]Alert, error)
+}
+
+// AlertSources queries several alert services, e.g. one for the US and one
+// for Europe, since each only covers its own region.
+type AlertSources []AlertSource
+
+// severities ranks the CAP severity levels.
+var severities = map[string]int{
+	"Extreme":  4,
+	"Severe":   3,
+	"Moderate": 2,
+	"Minor":    1,
+}
+
+// Alerts returns the alerts from every source that have not expired at
+// now, most severe first. A failing source is logged and skipped so that
+// the image is still generated.
+func (s AlertSources) Alerts(loc Location, now time.Time) []Alert {
+	var alerts []Alert
+	for _, src := range s {
+		found, err := src.Alerts(loc)
+		if err != nil {
+			logrus.Errorf("alert source %s failed: %v", src.Name(), err)
+			continue
+		}
+		for _, a := range found {
+			if a.Expires.IsZero() || a.Expires.After(now) {
+				alerts = append(alerts, a)
+			}
+		}
+	}
+	sort.SliceStable(alerts, func(i, j int) bool {
+		if si, sj := severities[alerts[i].Severity], severities[alerts[j].Severity]; si != sj {
+			return si > sj
+		}
+		return alerts[i].Expires.Before(alerts[j].Expires)
+	})
+	return alerts
+}
+
+// newAlertSources builds the configured alert sources.
+func newAlertSources(configs []AlertConfig) (AlertSources, error) {
+	var sources AlertSources
+	for _, c := range configs {
+		userAgent := c.UserAgent
+		if userAgent == "" {
+			userAgent = defaultUserAgent
+		}
+		switch canonicalAlertSource(c.Name) {
+		case "nws":
+			url := c.URL
+			if url == "" {
+				url = defaultNWSURL
+			}
+			sources = append(sources, NewNWSAlerts(url, userAgent))
+		case "meteoalarm":
+			sources = append(sources, NewMeteoAlarm(c.URL, userAgent))
+		default:
+			return nil, fmt.Errorf("unknown alert source %q", c.Name)
+		}
+	}
+	return sources, nil
+}
+
+// NWSAlerts implements AlertSource with the active alerts of the US
+// National Weather Service API.
+type NWSAlerts struct {
+	api *NWSProvider
+}
+
+func NewNWSAlerts(baseURL, userAgent string) *NWSAlerts {
+	return &NWSAlerts{api: NewNWSProvider(baseURL, userAgent)}
+}
+
+func (a *NWSAlerts) Name() string {
+	return "National Weather Service alerts"
+}
+
+func (a *NWSAlerts) Alerts(loc Location) ([]Alert, error) {
+	logrus.Info("getting nws alerts")
+	var resp struct {
+		Features []struct {
+			Properties struct {
+				Event       string     `json:"event"`
+				Severity    string     `json:"severity"`
+				Headline    string     `json:"headline"`
+				Status      string     `json:"status"`
+				MessageType string     `json:"messageType"`
+				Onset       *time.Time `json:"onset"`
+				Expires     *time.Time `json:"expires"`
+				Ends        *time.Time `json:"ends"`
+			} `json:"properties"`
+		} `json:"features"`
+	}
+	path := fmt.Sprintf("/alerts/active?point=%s,%s", strconv.FormatFloat(loc.Lat, 'f', 4, 64), strconv.FormatFloat(loc.Lon, 'f', 4, 64))
+	if err := a.api.get(path, &resp); err != nil {
+		return nil, fmt.Errorf("error getting nws alerts: %v", err)
+	}
+
+	var alerts []Alert
+	for _, f := range resp.Features {
+		p := f.Properties
+		if p.Status != "Actual" || p.MessageType == "Cancel" {
+			continue
+		}
+		alert := Alert{Event: p.Event, Severity: p.Severity, Headline: p.Headline, Source: a.Name()}
+		if p.Onset != nil {
+			alert.Onset = *p.Onset
+		}
+		// ends is when the hazard is over; expires only when the message is
+		// superseded.
+		if p.Ends != nil {
+			alert.Expires = *p.Ends
+		} else if p.Expires != nil {
+			alert.Expires = *p.Expires
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, nil
+}
+
+// MeteoAlarm implements AlertSource with a MeteoAlarm country Atom feed of
+// CAP alerts, such as
+// https://feeds.meteoalarm.org/feeds/meteoalarm-legacy-atom-germany. The
+// feed lists a country's warnings; those whose area polygons contain the
+// location apply to it.
+type MeteoAlarm struct {
+	url       string
+	userAgent string
+	client    *http.Client
+
+	mu sync.Mutex
+	// areas caches the polygons of CAP documents by URL. A CAP document is
+	// never changed once published, updates are issued as new documents.
+	// Documents are forgotten once the feed no longer lists them or they
+	// expire.
+	areas map[string][]capPolygon
+}
+
+func NewMeteoAlarm(url, userAgent string) *MeteoAlarm {
+	return &MeteoAlarm{
+		url:       url,
+		userAgent: userAgent,
+		client:    newHTTPClient(),
+		areas:     make(map[string][]capPolygon),
+	}
+}
+
+func (m *MeteoAlarm) Name() string {
+	return "MeteoAlarm"
+}
+
+type meteoAlarmEntry struct {
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Type string `xml:"type,attr"`
+	} `xml:"link"`
+	Event    string   `xml:"event"`
+	Severity string   `xml:"severity"`
+	Headline string   `xml:"title"`
+	Status   string   `xml:"status"`
+	MsgType  string   `xml:"msgType"`
+	Onset    string   `xml:"onset"`
+	Expires  string   `xml:"expires"`
+	Polygons []string `xml:"polygon"`
+}
+
+type capAlert struct {
+	Info []struct {
+		Areas []struct {
+			Polygons []string `xml:"polygon"`
+		} `xml:"area"`
+	} `xml:"info"`
+}
+
+func (m *MeteoAlarm) Alerts(loc Location) ([]Alert, error) {
+	logrus.Info("getting meteoalarm alerts")
+	var feed struct {
+		Entries []meteoAlarmEntry `xml:"entry"`
+	}
+	if err := m.getXML(m.url, &feed); err != nil {
+		return nil, fmt.Errorf("error getting meteoalarm feed: %v", err)
+	}
+
+	now := time.Now()
+	listed := make(map[string]bool)
+	var alerts []Alert
+	for _, e := range feed.Entries {
+		if (e.Status != "" && e.Status != "Actual") || e.MsgType == "Cancel" {
+			continue
+		}
+		// an expired entry's document is not fetched, or kept
+		expires, _ := time.Parse(time.RFC3339, strings.TrimSpace(e.Expires))
+		if !expires.IsZero() && !expires.After(now) {
+			continue
+		}
+		if link := e.capLink(); link != "" {
+			listed[link] = true
+		}
+		polygons, err := m.polygons(e)
+		if err != nil {
+			logrus.Errorf("skipping meteoalarm alert %q: %v", e.Event, err)
+			continue
+		}
+		if !polygonsContain(polygons, loc.Lat, loc.Lon) {
+			continue
+		}
+		alert := Alert{Event: e.Event, Severity: e.Severity, Headline: e.Headline, Source: m.Name()}
+		alert.Onset, _ = time.Parse(time.RFC3339, strings.TrimSpace(e.Onset))
+		alert.Expires = expires
+		alerts = append(alerts, alert)
+	}
+
+	m.mu.Lock()
+	for link := range m.areas {
+		if !listed[link] {
+			delete(m.areas, link)
+		}
+	}
+	m.mu.Unlock()
+	return alerts, nil
+}
+
+// capLink returns the URL of an entry's CAP document, or "" when it links
+// none.
+func (e meteoAlarmEntry) capLink() string {
+	var link string
+	for _, l := range e.Links {
+		if link == "" || strings.Contains(l.Type, "cap") {
+			link = l.Href
+		}
+	}
+	return link
+}
+
+// polygons returns the area of an entry, from the feed when it includes
+// polygons and otherwise from the linked CAP document.
+func (m *MeteoAlarm) polygons(e meteoAlarmEntry) ([]capPolygon, error) {
+	if len(e.Polygons) > 0 {
+		return parseCAPPolygons(e.Polygons), nil
+	}
+	link := e.capLink()
+	if link == "" {
+		return nil, fmt.Errorf("no area and no link to the CAP document")
+	}
+
+	m.mu.Lock()
+	polygons, ok := m.areas[link]
+	m.mu.Unlock()
+	if ok {
+		return polygons, nil
+	}
+	var doc capAlert
+	if err := m.getXML(link, &doc); err != nil {
+		return nil, err
+	}
+	for _, info := range doc.Info {
+		for _, area := range info.Areas {
+			polygons = append(polygons, parseCAPPolygons(area.Polygons)...)
+		}
+	}
+	m.mu.Lock()
+	m.areas[link] = polygons
+	m.mu.Unlock()
+	return polygons, nil
+}
+
+func (m *MeteoAlarm) getXML(url string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", m.userAgent)
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return xml.NewDecoder(resp.Body).Decode(v)
+}
+
+// capPolygon is a closed ring of latitude, longitude pairs.
+type capPolygon [][2]float64
+
+// parseCAPPolygons parses CAP polygons, whitespace separated "lat,lon"
+// pairs. Malformed points are skipped.
+func parseCAPPolygons(polygons []string) []capPolygon {
+	var parsed []capPolygon
+	for _, s := range polygons {
+		var p capPolygon
+		for _, pair := range strings.Fields(s) {
+			latLon := strings.Split(pair, ",")
+			if len(latLon) != 2 {
+				continue
+			}
+			lat, err1 := strconv.ParseFloat(latLon[0], 64)
+			lon, err2 := strconv.ParseFloat(latLon[1], 64)
+			if err1 == nil && err2 == nil {
+				p = append(p, [2]float64{lat, lon})
+			}
+		}
+		if len(p) >= 3 {
+			parsed = append(parsed, p)
+		}
+	}
+	return parsed
+}
+
+// polygonsContain reports whether any polygon contains the point, by ray
+// casting.
+func polygonsContain(polygons []capPolygon, lat, lon float64) bool {
+	for _, p := range polygons {
+		inside := false
+		for i, j := 0, len(p)-1; i < len(p); j, i = i, i+1 {
+			a, b := p[i], p[j]
+			if (a[0] > lat) != (b[0] > lat) && lon < (b[1]-a[1])*(lat-a[0])/(b[0]-a[0])+a[1] {
+				inside = !inside
+			}
+		}
+		if inside {
+			return true
+		}
+	}
+	return false
+}
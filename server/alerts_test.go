@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// meteoAlarmStandIn serves a MeteoAlarm Atom feed of the entries set on it
+// and a CAP document for each, counting the documents fetched.
+type meteoAlarmStandIn struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	entries []string
+	fetched map[string]int
+}
+
+// berlin is inside the area of CAP documents whose name starts with "berlin".
+var berlin = Location{Lat: 52.52, Lon: 13.4, TZ: time.UTC}
+
+func newMeteoAlarmStandIn(t *testing.T) *meteoAlarmStandIn {
+	t.Helper()
+	s := &meteoAlarmStandIn{fetched: make(map[string]int)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if r.URL.Path == "/feed" {
+			w.Header().Set("Content-Type", "application/atom+xml")
+			fmt.Fprintf(w, `<feed xmlns="http://www.w3.org/2005/Atom" xmlns:cap="urn:oasis:names:tc:emergency:cap:1.2">%s</feed>`, strings.Join(s.entries, ""))
+			return
+		}
+		name := strings.TrimPrefix(r.URL.Path, "/cap/")
+		s.fetched[name]++
+		polygon := "1,1 1,2 2,2 2,1 1,1"
+		if strings.HasPrefix(name, "berlin") {
+			polygon = "52,13 52,14 53,14 53,13 52,13"
+		}
+		w.Header().Set("Content-Type", "application/cap+xml")
+		fmt.Fprintf(w, `<alert xmlns="urn:oasis:names:tc:emergency:cap:1.2"><info><area><polygon>%s</polygon></area></info></alert>`, polygon)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// setEntries makes the feed list an entry for each CAP document name,
+// expiring at the given time.
+func (s *meteoAlarmStandIn) setEntries(expires map[string]time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = nil
+	for name, at := range expires {
+		s.entries = append(s.entries, fmt.Sprintf(`<entry><title>%[1]s</title><link href="%[2]s/cap/%[1]s" type="application/cap+xml"/>`+
+			`<cap:event>%[1]s</cap:event><cap:severity>Moderate</cap:severity><cap:status>Actual</cap:status>`+
+			`<cap:msgType>Alert</cap:msgType><cap:expires>%[3]s</cap:expires></entry>`, name, s.URL, at.Format(time.RFC3339)))
+	}
+}
+
+func (s *meteoAlarmStandIn) count(name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetched[name]
+}
+
+func cachedAreas(m *MeteoAlarm) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var links []string
+	for link := range m.areas {
+		links = append(links, link[strings.LastIndex(link, "/")+1:])
+	}
+	return links
+}
+
+// TestMeteoAlarmAreas checks that CAP documents are fetched once while the
+// feed lists them and forgotten once it does not or they expire.
+func TestMeteoAlarmAreas(t *testing.T) {
+	srv := newMeteoAlarmStandIn(t)
+	m := NewMeteoAlarm(srv.URL+"/feed", "test")
+	later := time.Now().Add(time.Hour)
+
+	srv.setEntries(map[string]time.Time{"berlin-wind": later, "elsewhere": later, "berlin-old": time.Now().Add(-time.Hour)})
+	for i := 0; i < 2; i++ {
+		alerts, err := m.Alerts(berlin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(alerts) != 1 || alerts[0].Event != "berlin-wind" {
+			t.Fatalf("alerts = %+v, want berlin-wind", alerts)
+		}
+	}
+	if n := srv.count("berlin-wind"); n != 1 {
+		t.Errorf("berlin-wind fetched %d times, want once", n)
+	}
+	if n := srv.count("berlin-old"); n != 0 {
+		t.Errorf("expired berlin-old fetched %d times", n)
+	}
+	if got := cachedAreas(m); len(got) != 2 {
+		t.Errorf("cached %v, want berlin-wind and elsewhere", got)
+	}
+
+	// elsewhere is withdrawn
+	srv.setEntries(map[string]time.Time{"berlin-wind": later})
+	if _, err := m.Alerts(berlin); err != nil {
+		t.Fatal(err)
+	}
+	if got := cachedAreas(m); len(got) != 1 || got[0] != "berlin-wind" {
+		t.Errorf("cached %v, want berlin-wind", got)
+	}
+
+	// berlin-wind expires while the feed still lists it
+	srv.setEntries(map[string]time.Time{"berlin-wind": time.Now().Add(-time.Minute)})
+	alerts, err := m.Alerts(berlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 0 {
+		t.Errorf("alerts = %+v, want none", alerts)
+	}
+	if got := cachedAreas(m); len(got) != 0 {
+		t.Errorf("cached %v, want none", got)
+	}
+}
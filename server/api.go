@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// apiForecast is the response of /api/v1/forecast: the data behind a
+// device's last image. It is kept apart from Forecast so the model can
+// change without breaking clients. Values are in the units named by Units,
+// see Forecast.
+type apiForecast struct {
+	Device     string         `json:"device"`
+	Source     string         `json:"source"`
+	FetchedAt  time.Time      `json:"fetched_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	Stale      bool           `json:"stale"`
+	Units      string         `json:"units"`
+	Location   apiLocation    `json:"location"`
+	Current    apiCurrent     `json:"current"`
+	Hourly     []apiHour      `json:"hourly"`
+	Daily      []apiDay       `json:"daily"`
+	AirQuality *apiAirQuality `json:"air_quality,omitempty"`
+	Pollen     *apiPollen     `json:"pollen,omitempty"`
+	Alerts     []apiAlert     `json:"alerts"`
+}
+
+type apiLocation struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Timezone  string  `json:"timezone"`
+}
+
+type apiCurrent struct {
+	Time          time.Time `json:"time"`
+	Temperature   float64   `json:"temperature"`
+	FeelsLike     float64   `json:"feels_like"`
+	Humidity      float64   `json:"humidity"`
+	WindSpeed     float64   `json:"wind_speed"`
+	WindDirection float64   `json:"wind_direction"`
+	Pressure      float64   `json:"pressure"`
+	Icon          string    `json:"icon"`
+	Sunrise       time.Time `json:"sunrise"`
+	Sunset        time.Time `json:"sunset"`
+	MoonPhase     string    `json:"moon_phase"`
+}
+
+type apiHour struct {
+	Time                     time.Time `json:"time"`
+	Temperature              float64   `json:"temperature"`
+	Precipitation            float64   `json:"precipitation"`
+	PrecipitationProbability float64   `json:"precipitation_probability"`
+	WindSpeed                float64   `json:"wind_speed"`
+	Icon                     string    `json:"icon"`
+}
+
+type apiDay struct {
+	// Date is the local calendar day, e.g. 2024-05-01.
+	Date          string     `json:"date"`
+	High          float64    `json:"high"`
+	Low           float64    `json:"low"`
+	Precipitation float64    `json:"precipitation"`
+	Icon          string     `json:"icon"`
+	Sunrise       *time.Time `json:"sunrise,omitempty"`
+	Sunset        *time.Time `json:"sunset,omitempty"`
+	MoonPhase     string     `json:"moon_phase,omitempty"`
+}
+
+type apiAirQuality struct {
+	AQI              int     `json:"aqi"`
+	PrimaryPollutant string  `json:"primary_pollutant,omitempty"`
+	PM25             float64 `json:"pm25"`
+}
+
+// apiPollen holds pollen levels from 0 (none) to 5 (very high).
+type apiPollen struct {
+	Tree  int `json:"tree"`
+	Grass int `json:"grass"`
+	Weed  int `json:"weed"`
+}
+
+type apiAlert struct {
+	Event    string     `json:"event"`
+	Severity string     `json:"severity"`
+	Headline string     `json:"headline"`
+	Onset    *time.Time `json:"onset,omitempty"`
+	Expires  *time.Time `json:"expires,omitempty"`
+	Source   string     `json:"source"`
+}
+
+// ServeForecast serves the forecast and alerts behind the image of the
+// device named by the device parameter, or the default device, as JSON at
+// /api/v1/forecast. It never calls the providers: the data is what the
+// device's schedule last fetched.
+func (s *Server) ServeForecast(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("device")
+	s.mu.Lock()
+	cfg := s.cfg
+	if cfg != nil && id == "" && len(cfg.Devices) == 0 {
+		id = "default"
+	}
+	f, ok := s.generators[id]
+	s.mu.Unlock()
+	switch {
+	case cfg == nil:
+		http.Error(w, "no valid configuration is running", http.StatusServiceUnavailable)
+		return
+	case id == "":
+		http.Error(w, "device: required when devices are configured", http.StatusBadRequest)
+		return
+	case !ok:
+		http.Error(w, fmt.Sprintf("device: unknown device %q", id), http.StatusNotFound)
+		return
+	}
+
+	device, forecast, alerts := f.Shown()
+	if forecast == nil {
+		http.Error(w, fmt.Sprintf("no forecast for %s yet", id), http.StatusServiceUnavailable)
+		return
+	}
+	body, err := json.MarshalIndent(newAPIForecast(device, forecast, alerts, time.Now()), "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	serveContent(w, r, "forecast.json", forecast.Updated(), body)
+}
+
+func newAPIForecast(device *Device, f *Forecast, alerts []Alert, now time.Time) *apiForecast {
+	c := f.Current
+	out := &apiForecast{
+		Device:    device.ID,
+		Source:    f.Source,
+		FetchedAt: f.FetchedAt,
+		UpdatedAt: f.Updated(),
+		Stale:     now.Sub(f.FetchedAt) > device.StaleAfter,
+		Units:     f.Units,
+		Location: apiLocation{
+			Latitude:  device.Location.Lat,
+			Longitude: device.Location.Lon,
+			Timezone:  device.Location.TZ.String(),
+		},
+		Current: apiCurrent{
+			Time:          c.Time,
+			Temperature:   c.Temp,
+			FeelsLike:     c.FeelsLike,
+			Humidity:      c.Humidity,
+			WindSpeed:     c.WindSpeed,
+			WindDirection: c.WindDirection,
+			Pressure:      c.Pressure,
+			Icon:          c.Icon,
+			Sunrise:       c.Sunrise,
+			Sunset:        c.Sunset,
+			MoonPhase:     c.MoonPhase,
+		},
+		Hourly: make([]apiHour, 0, len(f.Hourly)),
+		Daily:  make([]apiDay, 0, len(f.Daily)),
+		Alerts: make([]apiAlert, 0, len(alerts)),
+	}
+	for _, h := range f.Hourly {
+		out.Hourly = append(out.Hourly, apiHour{
+			Time:                     h.Time,
+			Temperature:              h.Temp,
+			Precipitation:            h.Precipitation,
+			PrecipitationProbability: h.PrecipProbability,
+			WindSpeed:                h.WindSpeed,
+			Icon:                     h.Icon,
+		})
+	}
+	for _, d := range f.Daily {
+		out.Daily = append(out.Daily, apiDay{
+			Date:          d.Date.Format("2006-01-02"),
+			High:          d.High,
+			Low:           d.Low,
+			Precipitation: d.Precipitation,
+			Icon:          d.Icon,
+			Sunrise:       optionalTime(d.Sunrise),
+			Sunset:        optionalTime(d.Sunset),
+			MoonPhase:     d.MoonPhase,
+		})
+	}
+	if aq := f.AirQuality; aq != nil {
+		out.AirQuality = &apiAirQuality{AQI: aq.AQI, PrimaryPollutant: aq.PrimaryPollutant, PM25: aq.PM25}
+	}
+	if p := f.Pollen; p != nil {
+		out.Pollen = &apiPollen{Tree: p.Tree, Grass: p.Grass, Weed: p.Weed}
+	}
+	for _, a := range alerts {
+		out.Alerts = append(out.Alerts, apiAlert{
+			Event:    a.Event,
+			Severity: a.Severity,
+			Headline: a.Headline,
+			Onset:    optionalTime(a.Onset),
+			Expires:  optionalTime(a.Expires),
+			Source:   a.Source,
+		})
+	}
+	return out
+}
+
+// optionalTime returns nil for the zero time, so it is left out of JSON.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
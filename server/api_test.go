@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func getForecast(s *Server, query string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", "/api/v1/forecast"+query, nil)
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	s.ServeForecast(w, r)
+	return w
+}
+
+func TestServeForecastStatus(t *testing.T) {
+	empty := &Server{generators: make(map[string]*FileGenerator)}
+	if w := getForecast(empty, "", nil); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("without a configuration: status %d, want 503", w.Code)
+	}
+
+	s := newTestServer(t, testConfig("default"))
+	for _, tt := range []struct {
+		query string
+		want  int
+	}{
+		{"", http.StatusBadRequest},
+		{"?device=kitchen", http.StatusNotFound},
+		// a before its first run
+		{"?device=a", http.StatusServiceUnavailable},
+	} {
+		if w := getForecast(s, tt.query, nil); w.Code != tt.want {
+			t.Errorf("%q: status %d, want %d: %s", tt.query, w.Code, tt.want, w.Body)
+		}
+	}
+}
+
+// TestServeForecast checks the JSON served for a device's last image, drawn
+// from the recorded Open-Meteo forecast.
+func TestServeForecast(t *testing.T) {
+	srv, _ := newOpenMeteoStandIn(t)
+	cfg := testConfig()
+	cfg.Providers = []ProviderConfig{{Name: "openmeteo", URL: srv.URL + "/v1/forecast", AirQualityURL: srv.URL + "/v1/air-quality"}}
+	s := newTestServer(t, cfg)
+	// without devices the defaults are served as the default device
+	f := s.generators["default"]
+	t.Cleanup(func() { forgetDevice(f.id) })
+	f.Run()
+
+	w := getForecast(s, "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type %q", ct)
+	}
+	etag := w.Header().Get("ETag")
+
+	var body struct {
+		Device    string `json:"device"`
+		Source    string `json:"source"`
+		FetchedAt string `json:"fetched_at"`
+		UpdatedAt string `json:"updated_at"`
+		Stale     *bool  `json:"stale"`
+		Units     string `json:"units"`
+		Location  struct {
+			Latitude  float64 `json:"latitude"`
+			Longitude float64 `json:"longitude"`
+			Timezone  string  `json:"timezone"`
+		} `json:"location"`
+		Current    map[string]interface{}   `json:"current"`
+		Hourly     []map[string]interface{} `json:"hourly"`
+		Daily      []map[string]interface{} `json:"daily"`
+		AirQuality map[string]interface{}   `json:"air_quality"`
+		Alerts     []interface{}            `json:"alerts"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Device != "default" || body.Source != "Open-Meteo" || body.Units != UnitsImperial || body.Stale == nil || *body.Stale {
+		t.Errorf("device %q, source %q, units %q, stale %v", body.Device, body.Source, body.Units, body.Stale)
+	}
+	if body.FetchedAt == "" || body.UpdatedAt == "" {
+		t.Errorf("fetched at %q, updated at %q", body.FetchedAt, body.UpdatedAt)
+	}
+	if l := body.Location; l.Latitude != 35.780361 || l.Longitude != -78.639111 || l.Timezone != "UTC" {
+		t.Errorf("location %+v", l)
+	}
+	for _, key := range []string{"time", "temperature", "feels_like", "humidity", "wind_speed", "wind_direction", "pressure", "icon", "sunrise", "sunset", "moon_phase"} {
+		if _, ok := body.Current[key]; !ok {
+			t.Errorf("current has no %s", key)
+		}
+	}
+	if len(body.Hourly) < 24 {
+		t.Errorf("%d hours, want at least 24", len(body.Hourly))
+	} else if len(body.Hourly[0]) != 6 || body.Hourly[0]["precipitation_probability"] == nil {
+		t.Errorf("hour %v", body.Hourly[0])
+	}
+	date := regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	if len(body.Daily) == 0 {
+		t.Error("no days")
+	}
+	for _, d := range body.Daily {
+		if s, _ := d["date"].(string); !date.MatchString(s) || d["high"] == nil || d["icon"] == nil {
+			t.Errorf("day %v", d)
+		}
+	}
+	// left out rather than null when the provider has no data, and an
+	// empty list of alerts
+	if body.AirQuality != nil {
+		t.Errorf("air quality %v without air quality data", body.AirQuality)
+	}
+	if body.Alerts == nil || len(body.Alerts) != 0 {
+		t.Errorf("alerts %v, want an empty list", body.Alerts)
+	}
+
+	if w := getForecast(s, "", http.Header{"If-None-Match": {etag}}); w.Code != http.StatusNotModified {
+		t.Errorf("status %d with the ETag, want 304", w.Code)
+	}
+	if w := getForecast(s, "?device=a", nil); w.Code != http.StatusNotFound {
+		t.Errorf("status %d for an unknown device, want 404", w.Code)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+var (
+	// knownNewMoon is the new moon of 2000-01-06 18:14 UTC.
+	knownNewMoon = time.Date(2000, time.January, 6, 18, 14, 0, 0, time.UTC)
+	synodicMonth = 29.530588853 * 24 * float64(time.Hour)
+
+	moonPhases = []string{"new", "waxing_crescent", "first_quarter", "waxing_gibbous", "full", "waning_gibbous", "last_quarter", "waning_crescent"}
+)
+
+// moonPhaseAt approximates the moon phase at t for providers that do not
+// report one.
+func moonPhaseAt(t time.Time) string {
+	age := math.Mod(float64(t.Sub(knownNewMoon)), synodicMonth)
+	if age < 0 {
+		age += synodicMonth
+	}
+	i := int(math.Floor(age/synodicMonth*8+0.5)) % 8
+	return moonPhases[i]
+}
+
+// sunriseSunset computes the sunrise and sunset at loc on the calendar day
+// of date using the sunrise equation, which is accurate to about a minute.
+// Zero times are returned during polar day or night.
+func sunriseSunset(loc Location, date time.Time) (time.Time, time.Time) {
+	const j2000 = 2451545.0
+	rad := math.Pi / 180
+
+	noon := time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, time.UTC)
+	n := math.Round(julianDay(noon) - j2000 + 0.0008)
+	meanSolarNoon := n - loc.Lon/360
+
+	m := math.Mod(357.5291+0.98560028*meanSolarNoon, 360)
+	c := 1.9148*math.Sin(m*rad) + 0.02*math.Sin(2*m*rad) + 0.0003*math.Sin(3*m*rad)
+	lambda := math.Mod(m+c+180+102.9372, 360)
+	transit := j2000 + meanSolarNoon + 0.0053*math.Sin(m*rad) - 0.0069*math.Sin(2*lambda*rad)
+
+	sinDecl := math.Sin(lambda*rad) * math.Sin(23.4397*rad)
+	cosDecl := math.Cos(math.Asin(sinDecl))
+	cosHourAngle := (math.Sin(-0.833*rad) - math.Sin(loc.Lat*rad)*sinDecl) / (math.Cos(loc.Lat*rad) * cosDecl)
+	if cosHourAngle < -1 || cosHourAngle > 1 {
+		return time.Time{}, time.Time{}
+	}
+	hourAngle := math.Acos(cosHourAngle) / rad
+	return fromJulianDay(transit - hourAngle/360), fromJulianDay(transit + hourAngle/360)
+}
+
+// polarDay reports whether the sun stays above or below the horizon at loc
+// all of t's calendar day there, so the day has no sunrise or sunset.
+func polarDay(loc Location, t time.Time) bool {
+	if loc.TZ != nil {
+		t = t.In(loc.TZ)
+	}
+	rise, _ := sunriseSunset(loc, t)
+	return rise.IsZero()
+}
+
+// sunElevation approximates the elevation of the sun's centre above the
+// horizon at loc and t, in degrees, to within about a degree.
+func sunElevation(loc Location, t time.Time) float64 {
+	const j2000 = 2451545.0
+	rad := math.Pi / 180
+
+	d := julianDay(t) - j2000
+	m := math.Mod(357.5291+0.98560028*d, 360)
+	c := 1.9148*math.Sin(m*rad) + 0.02*math.Sin(2*m*rad) + 0.0003*math.Sin(3*m*rad)
+	lambda := math.Mod(m+c+180+102.9372, 360)
+
+	obliquity := 23.4397 * rad
+	decl := math.Asin(math.Sin(lambda*rad) * math.Sin(obliquity))
+	ascension := math.Atan2(math.Sin(lambda*rad)*math.Cos(obliquity), math.Cos(lambda*rad))
+	sidereal := math.Mod(280.1470+360.9856235*d+loc.Lon, 360) * rad
+	hourAngle := sidereal - ascension
+	lat := loc.Lat * rad
+	return math.Asin(math.Sin(lat)*math.Sin(decl)+math.Cos(lat)*math.Cos(decl)*math.Cos(hourAngle)) / rad
+}
+
+func julianDay(t time.Time) float64 {
+	return float64(t.Unix())/86400 + 2440587.5
+}
+
+func fromJulianDay(jd float64) time.Time {
+	return time.Unix(int64(math.Round((jd-2440587.5)*86400)), 0).UTC()
+}
+
+// calendarDate returns the calendar day of t, in t's own location, as
+// midnight UTC.
+func calendarDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func tromso(t *testing.T) Location {
+	t.Helper()
+	tz, err := time.LoadLocation("Europe/Oslo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Location{Lat: 69.65, Lon: 18.96, TZ: tz}
+}
+
+// near reports whether a is within two minutes of b.
+func near(a, b time.Time) bool {
+	d := a.Sub(b)
+	return d > -2*time.Minute && d < 2*time.Minute
+}
+
+func TestSunriseSunset(t *testing.T) {
+	loc := raleigh(t)
+	rise, set := sunriseSunset(loc, time.Date(2025, 10, 17, 0, 0, 0, 0, time.UTC))
+	// 7:22 and 18:36 EDT
+	if want := time.Date(2025, 10, 17, 11, 22, 0, 0, time.UTC); !near(rise, want) {
+		t.Errorf("sunrise %s, want about %s", rise, want)
+	}
+	if want := time.Date(2025, 10, 17, 22, 36, 0, 0, time.UTC); !near(set, want) {
+		t.Errorf("sunset %s, want about %s", set, want)
+	}
+	if e := sunElevation(loc, rise); e < -1.5 || e > 0 {
+		t.Errorf("elevation at sunrise %.2f°, want about -0.83°", e)
+	}
+	if polarDay(loc, rise) {
+		t.Error("Raleigh has a polar day")
+	}
+
+	far := tromso(t)
+	for _, at := range []time.Time{
+		time.Date(2026, 12, 20, 12, 0, 0, 0, far.TZ),
+		time.Date(2026, 6, 21, 0, 30, 0, 0, far.TZ),
+	} {
+		if rise, set := sunriseSunset(far, at); !rise.IsZero() || !set.IsZero() {
+			t.Errorf("Tromsø on %s: sunrise %s, sunset %s", at, rise, set)
+		}
+		if !polarDay(far, at) {
+			t.Errorf("Tromsø on %s is not a polar day", at)
+		}
+	}
+}
+
+// TestPolarForecast checks that forecasts without a sunrise or sunset are
+// accepted while the sun does not rise or set, with icons by the sun.
+func TestPolarForecast(t *testing.T) {
+	far := tromso(t)
+	layout, err := parseLayout("test", `{{time .Forecast.Current.Sunrise}} {{time .Forecast.Current.Sunset}}|{{icon "clear" .Now}}`+
+		`<symbol id="clear_day"/><symbol id="clear_night"/>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dc := defaultConfig().Defaults
+	dc.Location = LocationConfig{Latitude: &far.Lat, Longitude: &far.Lon, Timezone: "Europe/Oslo"}
+	device, err := newDevice("test", dc, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		at    time.Time
+		icon  string
+		valid bool
+	}{
+		{time.Date(2026, 12, 20, 12, 0, 0, 0, far.TZ), "clear_night", true},
+		{time.Date(2026, 6, 21, 0, 30, 0, 0, far.TZ), "clear_day", true},
+		// the sun rises in October, so its times are missing data
+		{time.Date(2026, 10, 17, 12, 0, 0, 0, far.TZ), "", false},
+	} {
+		f := &Forecast{
+			FetchedAt: tt.at,
+			Current:   Current{Time: tt.at, Icon: "clear"},
+			Daily:     []Day{{Date: calendarDate(tt.at)}},
+		}
+		if err := f.validate(far); (err == nil) != tt.valid {
+			t.Errorf("%s: validate = %v, want valid %v", tt.at, err, tt.valid)
+		}
+		if tt.icon == "" {
+			continue
+		}
+		if got := getWeatherIcon("clear", f.dayOrNight(far, tt.at)); got != tt.icon {
+			t.Errorf("%s: icon %s, want %s", tt.at, got, tt.icon)
+		}
+		var out bytes.Buffer
+		if err := layout.Execute(&out, newTemplateData(f, nil, device, tt.at)); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := strings.SplitN(out.String(), "<", 2)[0], "– –|"+tt.icon; got != want {
+			t.Errorf("%s: layout shows %q, want %q", tt.at, got, want)
+		}
+	}
+}
+
+func TestDayOrNight(t *testing.T) {
+	loc := raleigh(t)
+	var f Forecast
+	for i := 0; i < 2; i++ {
+		date := time.Date(2025, 10, 17+i, 0, 0, 0, 0, time.UTC)
+		rise, set := sunriseSunset(loc, date)
+		f.Daily = append(f.Daily, Day{Date: date, Sunrise: rise, Sunset: set})
+	}
+	f.Current.Sunrise, f.Current.Sunset = f.Daily[0].Sunrise, f.Daily[0].Sunset
+
+	for _, tt := range []struct {
+		hour int
+		want string
+	}{
+		{6, "night"}, {12, "day"}, {20, "night"},
+		// the next day, by its own sunrise and sunset
+		{24 + 12, "day"}, {24 + 22, "night"},
+		// past the forecast's days, by the sun
+		{3*24 + 12, "day"}, {3*24 + 2, "night"},
+	} {
+		at := time.Date(2025, 10, 17, 0, 0, 0, 0, loc.TZ).Add(time.Duration(tt.hour) * time.Hour)
+		if got := f.dayOrNight(loc, at); got != tt.want {
+			t.Errorf("%s: %s, want %s", at, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// ForecastCache is a WeatherProvider that remembers the last forecast
+// fetched for each location, so on-demand renders can reuse the data the
+// devices' schedules fetched instead of calling the providers again. Only
+// the devices' runs fetch, so it holds no more than a forecast per device.
+type ForecastCache struct {
+	provider WeatherProvider
+	maxAge   time.Duration
+
+	mu        sync.Mutex
+	forecasts map[forecastKey]*Forecast
+}
+
+// forecastKey identifies a forecast. The timezone is part of it because
+// providers that aggregate days do so in the location's timezone.
+type forecastKey struct {
+	lat, lon float64
+	tz       string
+	units    string
+}
+
+// NewForecastCache caches the forecasts of provider. Forecasts older than
+// maxAge are never returned by Lookup and are dropped as others are added.
+func NewForecastCache(provider WeatherProvider, maxAge time.Duration) *ForecastCache {
+	return &ForecastCache{
+		provider:  provider,
+		maxAge:    maxAge,
+		forecasts: make(map[forecastKey]*Forecast),
+	}
+}
+
+func (c *ForecastCache) Name() string {
+	return c.provider.Name()
+}
+
+// Forecast always asks the provider and caches the result.
+func (c *ForecastCache) Forecast(loc Location, units string) (*Forecast, error) {
+	forecast, err := c.provider.Forecast(loc, units)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, f := range c.forecasts {
+		if now.Sub(f.FetchedAt) > c.maxAge {
+			delete(c.forecasts, key)
+		}
+	}
+	c.forecasts[newForecastKey(loc, units)] = forecast
+	return forecast, nil
+}
+
+// Lookup returns the cached forecast for loc when it is younger than the
+// cache's maxAge. It never asks the provider.
+func (c *ForecastCache) Lookup(loc Location, units string) (*Forecast, bool) {
+	c.mu.Lock()
+	forecast, ok := c.forecasts[newForecastKey(loc, units)]
+	c.mu.Unlock()
+	if !ok || time.Since(forecast.FetchedAt) > c.maxAge {
+		return nil, false
+	}
+	return forecast, true
+}
+
+func newForecastKey(loc Location, units string) forecastKey {
+	return forecastKey{lat: loc.Lat, lon: loc.Lon, tz: loc.TZ.String(), units: units}
+}
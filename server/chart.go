@@ -0,0 +1,176 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// chartHours is the time span of the hourly chart.
+const chartHours = 24
+
+// Layouts draw each day of a day grid in a box of this size, which the
+// grid scales to fit.
+const (
+	dayCellWidth  = 200
+	dayCellHeight = 330
+)
+
+// HourlyChart is an hourly temperature and precipitation probability chart
+// laid out in a box of the layout. Paths are SVG path data in the layout's
+// coordinates, so the chart needs no scripting or external tools to render.
+type HourlyChart struct {
+	// Temperature is the temperature line.
+	Temperature string
+	// Precipitation is one bar per hour, the full box height being 100%.
+	Precipitation string
+	// Ticks mark every third hour in the device's timezone.
+	Ticks []ChartTick
+	// High and Low are the warmest and coldest temperatures shown, in the
+	// device's units, and the heights at which they are drawn.
+	High, Low   string
+	HighY, LowY float64
+	// PrecipTotal is the precipitation over the hours shown in the
+	// forecast's units, for the precip function.
+	PrecipTotal float64
+	// Left, Top, Right and Bottom are the edges of the chart's box, for
+	// drawing axes and labels around it.
+	Left, Top, Right, Bottom float64
+}
+
+// ChartTick is an hour mark on the chart's time axis.
+type ChartTick struct {
+	X     float64
+	Label string
+	// Midnight is set on the first hour of a day.
+	Midnight bool
+}
+
+// hourlyChart lays out the chartHours hours of f starting with the hour of
+// now in the box at x, y of width w and height h. Temperatures are
+// converted to units and ticks placed in tz.
+func hourlyChart(f *Forecast, now time.Time, units Units, locale *Locale, tz *time.Location, x, y, w, h float64) *HourlyChart {
+	start := now.Truncate(time.Hour)
+	end := start.Add(chartHours * time.Hour)
+	posX := func(t time.Time) float64 {
+		return x + w*t.Sub(start).Hours()/chartHours
+	}
+
+	var hours []Hour
+	for _, hour := range f.Hourly {
+		if !hour.Time.Before(start) && hour.Time.Before(end) {
+			hours = append(hours, hour)
+		}
+	}
+
+	c := &HourlyChart{Left: x, Top: y, Right: x + w, Bottom: y + h}
+	for t := start; t.Before(end); t = t.Add(time.Hour) {
+		local := t.In(tz)
+		if local.Hour()%3 != 0 {
+			continue
+		}
+		c.Ticks = append(c.Ticks, ChartTick{
+			X:        posX(t),
+			Label:    locale.format(local, locale.HourFormat),
+			Midnight: local.Hour() == 0,
+		})
+	}
+	if len(hours) == 0 {
+		return c
+	}
+
+	low, high := math.Inf(1), math.Inf(-1)
+	temps := make([]float64, len(hours))
+	for i, hour := range hours {
+		temps[i] = units.Temperature(hour.Temp, f.Units)
+		low, high = math.Min(low, temps[i]), math.Max(high, temps[i])
+	}
+	c.High = strconv.FormatFloat(high, 'f', 0, 64)
+	c.Low = strconv.FormatFloat(low, 'f', 0, 64)
+	// Keep a flat day from being drawn as a jagged line and leave a margin
+	// for the line's width.
+	top, bottom := high, low
+	if span := high - low; span < 5 {
+		top, bottom = high+(5-span)/2, low-(5-span)/2
+	}
+	margin := h * 0.1
+	posY := func(temp float64) float64 {
+		return y + margin + (h-2*margin)*(top-temp)/(top-bottom)
+	}
+	c.HighY, c.LowY = posY(high), posY(low)
+
+	// Points sit in the middle of their hour, above the bar.
+	slot := w / chartHours
+	var line, bars strings.Builder
+	for i, hour := range hours {
+		mid := posX(hour.Time) + slot/2
+		cmd := "L"
+		if i == 0 {
+			cmd = "M"
+		}
+		line.WriteString(cmd + coord(mid) + "," + coord(posY(temps[i])))
+		c.PrecipTotal += hour.Precipitation
+
+		if hour.PrecipProbability > 0 {
+			barH := h * math.Min(hour.PrecipProbability, 100) / 100
+			bars.WriteString("M" + coord(mid-slot*0.3) + "," + coord(y+h) +
+				"v-" + coord(barH) + "h" + coord(slot*0.6) + "v" + coord(barH) + "z")
+		}
+	}
+	c.Temperature = line.String()
+	c.Precipitation = bars.String()
+	return c
+}
+
+func coord(v float64) string {
+	return strconv.FormatFloat(v, 'f', 1, 64)
+}
+
+// DayCell places one day of a day grid. Layouts draw the day in a
+// dayCellWidth by dayCellHeight box translated to X, Y and scaled by Scale.
+type DayCell struct {
+	// Day is nil for a day the provider did not return.
+	Day   *Day
+	X, Y  float64
+	Scale float64
+	// Last is set on the last cell of a row.
+	Last bool
+}
+
+// dayGrid lays out days in one or two rows in the box at x, y of width w
+// and height h, picking whichever draws the days larger. The grid is
+// centred in the box.
+func dayGrid(days []*Day, x, y, w, h float64) []DayCell {
+	if len(days) == 0 {
+		return nil
+	}
+	rows, scale := 1, 0.0
+	for r := 1; r <= 2 && r <= len(days); r++ {
+		cols := (len(days) + r - 1) / r
+		s := math.Min(w/float64(cols*dayCellWidth), h/float64(r*dayCellHeight))
+		if s > scale {
+			rows, scale = r, s
+		}
+	}
+	cols := (len(days) + rows - 1) / rows
+	top := y + (h-float64(rows)*dayCellHeight*scale)/2
+
+	cells := make([]DayCell, len(days))
+	for i, d := range days {
+		row, col := i/cols, i%cols
+		inRow := cols
+		if row == rows-1 {
+			inRow = len(days) - row*cols
+		}
+		rowWidth := float64(inRow) * dayCellWidth * scale
+		cells[i] = DayCell{
+			Day:   d,
+			X:     x + (w-rowWidth)/2 + float64(col)*dayCellWidth*scale,
+			Y:     top + float64(row)*dayCellHeight*scale,
+			Scale: scale,
+			Last:  col == inRow-1,
+		}
+	}
+	return cells
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/andyhaskell/climacell-go"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultClimaCellRealTimeURL = "https://api.climacell.co/v3/weather/realtime"
+
+// ClimaCellProvider implements WeatherProvider on top of the ClimaCell v3 API.
+type ClimaCellProvider struct {
+	c *climacell.Client
+	// realTimeURL is requested directly for the pollen fields, which the
+	// client library does not decode.
+	realTimeURL string
+	apiKey      string
+	client      *http.Client
+}
+
+func NewClimaCellProvider(apiKey string) *ClimaCellProvider {
+	return &ClimaCellProvider{
+		c:           climacell.New(apiKey),
+		realTimeURL: defaultClimaCellRealTimeURL,
+		apiKey:      apiKey,
+		client:      newHTTPClient(),
+	}
+}
+
+func (p *ClimaCellProvider) Name() string {
+	return "ClimaCell"
+}
+
+func (p *ClimaCellProvider) Forecast(loc Location, units string) (*Forecast, error) {
+	start := time.Now()
+	latLon := &climacell.LatLon{Lat: loc.Lat, Lon: loc.Lon}
+	unitSystem := "us"
+	if units == UnitsMetric {
+		unitSystem = "si"
+	}
+
+	logrus.Info("getting realtime data")
+	current, err := p.c.RealTime(climacell.ForecastArgs{
+		Location:   latLon,
+		UnitSystem: unitSystem,
+		Fields:     []string{realTimeFields},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting realTime data: %w", climaCellError(err))
+	}
+
+	logrus.Info("getting hourly forecast data")
+	hourly, err := p.c.HourlyForecast(climacell.ForecastArgs{
+		Location:   latLon,
+		UnitSystem: unitSystem,
+		Fields:     []string{hourlyFields},
+		Start:      start,
+		End:        start.Add(24 * time.Hour),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting hourly forecast data: %w", climaCellError(err))
+	}
+
+	logrus.Info("getting daily forecast data")
+	daily, err := p.c.DailyForecast(climacell.ForecastArgs{
+		Location:   latLon,
+		UnitSystem: unitSystem,
+		Fields:     []string{dailyFields},
+		Start:      start,
+		End:        time.Now().Add(24 * (maxForecastDays + 1) * time.Hour),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting forecast data: %w", climaCellError(err))
+	}
+
+	forecast := &Forecast{
+		Source:    p.Name(),
+		FetchedAt: start,
+		Units:     units,
+		Current:   climaCellCurrent(current),
+	}
+	if aqi, ok := current.EpaAQI.GetValue(); ok {
+		aq := &AirQuality{AQI: aqi}
+		aq.PrimaryPollutant, _ = current.EPAPrimaryPollutant.GetValue()
+		aq.PM25, _ = current.PMTwoPointFive.GetValue()
+		forecast.AirQuality = aq
+	}
+	// The forecast is complete without pollen, so a failure here is not a
+	// reason to try the next provider.
+	if forecast.Pollen, err = p.pollen(latLon, unitSystem); err != nil {
+		logrus.Warnf("error getting climacell pollen: %v", err)
+	}
+	for _, h := range hourly {
+		forecast.Hourly = append(forecast.Hourly, climaCellHour(h))
+	}
+	if units == UnitsMetric {
+		// SI wind speeds are in m/s.
+		forecast.Current.WindSpeed *= 3.6
+		for i := range forecast.Hourly {
+			forecast.Hourly[i].WindSpeed *= 3.6
+		}
+	}
+	for _, d := range daily {
+		forecast.Daily = append(forecast.Daily, climaCellDay(d))
+	}
+	return forecast, nil
+}
+
+// climaCellPollen is a realtime response with only the pollen fields, each
+// on ClimaCell's index of 0 (none) to 5 (very high).
+type climaCellPollen struct {
+	Tree  *climaCellIndex `json:"pollen_tree"`
+	Grass *climaCellIndex `json:"pollen_grass"`
+	Weed  *climaCellIndex `json:"pollen_weed"`
+}
+
+type climaCellIndex struct {
+	Value *int `json:"value"`
+}
+
+// pollen returns the current pollen levels, or nil when ClimaCell has no
+// data for the location.
+func (p *ClimaCellProvider) pollen(loc *climacell.LatLon, unitSystem string) (*Pollen, error) {
+	q := url.Values{}
+	q.Set("lat", strconv.FormatFloat(loc.Lat, 'f', -1, 64))
+	q.Set("lon", strconv.FormatFloat(loc.Lon, 'f', -1, 64))
+	q.Set("unit_system", unitSystem)
+	q.Set("fields", "pollen_tree,pollen_grass,pollen_weed")
+	req, err := http.NewRequest(http.MethodGet, p.realTimeURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("apikey", p.apiKey)
+
+	logrus.Info("getting realtime pollen data")
+	var resp climaCellPollen
+	if err := doJSON(p.client, req, &resp); err != nil {
+		return nil, err
+	}
+	index := func(i *climaCellIndex) (int, bool) {
+		if i == nil || i.Value == nil {
+			return 0, false
+		}
+		return *i.Value, true
+	}
+	tree, treeOK := index(resp.Tree)
+	grass, grassOK := index(resp.Grass)
+	weed, weedOK := index(resp.Weed)
+	if !treeOK && !grassOK && !weedOK {
+		return nil, nil
+	}
+	return &Pollen{Tree: tree, Grass: grass, Weed: weed}, nil
+}
+
+// climaCellError turns the API's error responses into a StatusError.
+func climaCellError(err error) error {
+	if e, ok := err.(*climacell.ErrorResponse); ok {
+		return &StatusError{StatusCode: e.StatusCode, msg: e.Error()}
+	}
+	return err
+}
+
+func climaCellCurrent(rt climacell.RealTime) Current {
+	c := Current{Time: rt.ObservationTime.Value}
+	c.Temp, _ = rt.Temp.GetValue()
+	c.FeelsLike, _ = rt.FeelsLike.GetValue()
+	c.Humidity, _ = rt.Humidity.GetValue()
+	c.WindSpeed, _ = rt.WindSpeed.GetValue()
+	c.WindDirection, _ = rt.WindDirection.GetValue()
+	c.Pressure, _ = rt.BaroPressure.GetValue()
+	c.Icon, _ = rt.WeatherCode.GetValue()
+	c.Sunrise, _ = rt.Sunrise.GetValue()
+	c.Sunset, _ = rt.Sunset.GetValue()
+	c.MoonPhase, _ = rt.MoonPhase.GetValue()
+	return c
+}
+
+func climaCellHour(hf climacell.HourlyForecast) Hour {
+	h := Hour{Time: hf.ObservationTime.Value}
+	h.Temp, _ = hf.Temp.GetValue()
+	h.Precipitation, _ = hf.Precipitation.GetValue()
+	h.PrecipProbability, _ = hf.PrecipitationProbability.GetValue()
+	h.WindSpeed, _ = hf.WindSpeed.GetValue()
+	h.Icon, _ = hf.WeatherCode.GetValue()
+	return h
+}
+
+func climaCellDay(fd climacell.ForecastDay) Day {
+	d := Day{Date: fd.ObservationTime.Value}
+	if fd.Temp != nil {
+		d.High, _ = fd.Temp.Max().GetValue()
+		d.Low, _ = fd.Temp.Min().GetValue()
+	}
+	d.Precipitation, _ = fd.PrecipitationAccumulation.GetValue()
+	d.Icon, _ = fd.WeatherCode.GetValue()
+	d.Sunrise, _ = fd.Sunrise.GetValue()
+	d.Sunset, _ = fd.Sunset.GetValue()
+	d.MoonPhase, _ = fd.MoonPhase.GetValue()
+	return d
+}
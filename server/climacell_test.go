@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andyhaskell/climacell-go"
+)
+
+func TestClimaCellPollen(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		body string
+		want *Pollen
+	}{
+		{"levels", `{"lat":35.78,"lon":-78.64,"pollen_tree":{"value":3,"units":"Climacell Pollen Index"},"pollen_grass":{"value":1,"units":"Climacell Pollen Index"},"pollen_weed":{"value":0,"units":"Climacell Pollen Index"}}`, &Pollen{Tree: 3, Grass: 1}},
+		{"partial", `{"pollen_tree":{"value":null},"pollen_grass":{"value":2}}`, &Pollen{Grass: 2}},
+		{"no data", `{"lat":35.78,"lon":-78.64,"pollen_tree":{"value":null},"pollen_grass":{"value":null},"pollen_weed":{"value":null}}`, nil},
+	} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			if r.Header.Get("apikey") != "key" || q.Get("fields") != "pollen_tree,pollen_grass,pollen_weed" || q.Get("lat") != "35.78" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"statusCode":400,"errorCode":"BadRequest","message":"bad request"}`))
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(tt.body))
+		}))
+		p := NewClimaCellProvider("key")
+		p.realTimeURL = srv.URL
+		got, err := p.pollen(&climacell.LatLon{Lat: 35.78, Lon: -78.64}, "us")
+		srv.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("%s: pollen = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,545 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the server configuration. It is read from a YAML file and then
+// overridden key by key from the environment, so a deployment configured
+// only through environment variables keeps working.
+type Config struct {
+	Providers []ProviderConfig `yaml:"providers"`
+	Renderer  string           `yaml:"renderer"`
+	FontPath  string           `yaml:"font_path"`
+	// TemplatesDir holds layouts as <name>.svg, replacing or adding to the
+	// built-in ones.
+	TemplatesDir string `yaml:"templates_dir"`
+	// Alerts are the weather warning services queried for every device.
+	Alerts []AlertConfig `yaml:"alerts"`
+	// Defaults apply to every device that does not set a key itself.
+	Defaults DeviceConfig   `yaml:"defaults"`
+	Devices  []DeviceConfig `yaml:"devices"`
+}
+
+// ProviderConfig configures one entry of the provider failover chain.
+type ProviderConfig struct {
+	Name      string `yaml:"name"`
+	APIKey    string `yaml:"api_key"`
+	URL       string `yaml:"url"`
+	UserAgent string `yaml:"user_agent"`
+	// AirQualityURL is the air quality API of providers that read it
+	// separately from the forecast, only openmeteo.
+	AirQualityURL string `yaml:"air_quality_url"`
+}
+
+// AlertConfig configures one alert source.
+type AlertConfig struct {
+	Name      string `yaml:"name"`
+	URL       string `yaml:"url"`
+	UserAgent string `yaml:"user_agent"`
+}
+
+// DeviceConfig configures one device. Unset keys are taken from
+// Config.Defaults.
+type DeviceConfig struct {
+	ID            string         `yaml:"id"`
+	Location      LocationConfig `yaml:"location"`
+	Profile       string         `yaml:"profile"`
+	Rotation      *int           `yaml:"rotation"`
+	GrayLevels    int            `yaml:"gray_levels"`
+	Dither        string         `yaml:"dither"`
+	Schedule      string         `yaml:"schedule"`
+	AlertSchedule string         `yaml:"alert_schedule"`
+	Units         string         `yaml:"units"`
+	WindUnit      string         `yaml:"wind_unit"`
+	Locale        string         `yaml:"locale"`
+	Layout        string         `yaml:"layout"`
+	ForecastDays  int            `yaml:"forecast_days"`
+	AirQuality    *bool          `yaml:"air_quality"`
+	StaleAfter    string         `yaml:"stale_after"`
+}
+
+type LocationConfig struct {
+	Latitude  *float64 `yaml:"latitude"`
+	Longitude *float64 `yaml:"longitude"`
+	Timezone  string   `yaml:"timezone"`
+}
+
+// ConfigError lists every problem found while loading a configuration.
+type ConfigError struct {
+	Source   string
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("invalid configuration in %s:\n  %s", e.Source, strings.Join(e.Problems, "\n  "))
+}
+
+func (e *ConfigError) addf(format string, args ...interface{}) {
+	e.Problems = append(e.Problems, fmt.Sprintf(format, args...))
+}
+
+// providerNames maps accepted provider names to their canonical name. The
+// canonical name, uppercased, prefixes the provider's environment variables.
+var providerNames = map[string]string{
+	"climacell":   "climacell",
+	"openmeteo":   "openmeteo",
+	"open-meteo":  "openmeteo",
+	"tomorrow":    "tomorrow",
+	"tomorrow.io": "tomorrow",
+	"nws":         "nws",
+	"metno":       "metno",
+	"met.no":      "metno",
+}
+
+// alertSourceNames maps accepted alert source names to their canonical
+// name. The canonical name, uppercased and followed by _ALERTS, prefixes the
+// source's environment variables.
+var alertSourceNames = map[string]string{
+	"nws":        "nws",
+	"meteoalarm": "meteoalarm",
+}
+
+// defaultStaleAfter is how old the last forecast can get while the providers
+// fail before the image says so.
+const defaultStaleAfter = "2h"
+
+// minStaleAfter keeps the staleness banner from showing on every hiccup.
+const minStaleAfter = 5 * time.Minute
+
+// defaultAlertSchedule refreshes the alerts of images every two minutes
+// while an alert is in effect.
+const defaultAlertSchedule = "*/2 * * * *"
+
+func defaultConfig() *Config {
+	lat, lon := 35.780361, -78.639111
+	return &Config{
+		Providers: []ProviderConfig{{Name: "climacell"}},
+		Renderer:  "builtin",
+		Defaults: DeviceConfig{
+			Location:      LocationConfig{Latitude: &lat, Longitude: &lon, Timezone: "UTC"},
+			Profile:       "kindle3",
+			Dither:        DitherNone,
+			Schedule:      defaultCron,
+			AlertSchedule: defaultAlertSchedule,
+			Units:         "imperial",
+			Locale:        "en",
+			Layout:        "default",
+			ForecastDays:  3,
+			StaleAfter:    defaultStaleAfter,
+		},
+	}
+}
+
+// LoadConfig reads the YAML file at path, applies environment overrides and
+// validates the result. A missing file is only an error when required is
+// set; otherwise the configuration comes from the environment alone.
+func LoadConfig(path string, required bool) (*Config, error) {
+	cfg := defaultConfig()
+	source := "environment"
+
+	data, err := ioutil.ReadFile(path)
+	switch {
+	case err == nil:
+		source = path
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && err != io.EOF {
+			return nil, &ConfigError{Source: path, Problems: []string{err.Error()}}
+		}
+	case !os.IsNotExist(err) || required:
+		return nil, fmt.Errorf("error reading config: %v", err)
+	}
+
+	errs := &ConfigError{Source: source}
+	cfg.applyEnv(errs)
+	cfg.validate(errs)
+	if len(errs.Problems) > 0 {
+		return nil, errs
+	}
+	return cfg, nil
+}
+
+// applyEnv overrides configuration keys from the environment. Values that
+// do not parse are reported rather than silently replaced by defaults.
+func (c *Config) applyEnv(errs *ConfigError) {
+	if v, ok := os.LookupEnv("WEATHER_PROVIDER"); ok && v != "" {
+		var providers []ProviderConfig
+		for _, name := range strings.Split(v, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			p := ProviderConfig{Name: name}
+			for _, existing := range c.Providers {
+				if canonicalProvider(existing.Name) == canonicalProvider(name) {
+					p = existing
+				}
+			}
+			providers = append(providers, p)
+		}
+		c.Providers = providers
+	}
+	for i := range c.Providers {
+		p := &c.Providers[i]
+		prefix := strings.ToUpper(canonicalProvider(p.Name)) + "_"
+		envString(prefix+"API_KEY", &p.APIKey)
+		envString(prefix+"URL", &p.URL)
+		envString(prefix+"USER_AGENT", &p.UserAgent)
+		envString(prefix+"AIR_QUALITY_URL", &p.AirQualityURL)
+	}
+	if v, ok := os.LookupEnv("ALERTS"); ok {
+		var alerts []AlertConfig
+		for _, name := range strings.Split(v, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			a := AlertConfig{Name: name}
+			for _, existing := range c.Alerts {
+				if canonicalAlertSource(existing.Name) == canonicalAlertSource(name) {
+					a = existing
+				}
+			}
+			alerts = append(alerts, a)
+		}
+		c.Alerts = alerts
+	}
+	for i := range c.Alerts {
+		a := &c.Alerts[i]
+		prefix := strings.ToUpper(canonicalAlertSource(a.Name)) + "_ALERTS_"
+		envString(prefix+"URL", &a.URL)
+		envString(prefix+"USER_AGENT", &a.UserAgent)
+	}
+	envString("RENDERER", &c.Renderer)
+	envString("FONT_PATH", &c.FontPath)
+	envString("TEMPLATES_DIR", &c.TemplatesDir)
+
+	c.Defaults.applyEnv("", errs)
+
+	if v, ok := os.LookupEnv("DEVICES"); ok && strings.TrimSpace(v) != "" {
+		var devices []DeviceConfig
+		for _, id := range strings.Split(v, ",") {
+			d := DeviceConfig{ID: strings.TrimSpace(id)}
+			for _, existing := range c.Devices {
+				if existing.ID == d.ID {
+					d = existing
+				}
+			}
+			devices = append(devices, d)
+		}
+		c.Devices = devices
+	}
+	for i := range c.Devices {
+		d := &c.Devices[i]
+		d.applyEnv("DEVICE_"+strings.ToUpper(strings.Replace(d.ID, "-", "_", -1))+"_", errs)
+	}
+}
+
+// applyEnv overrides device keys from environment variables named prefix
+// followed by the key, e.g. DEVICE_KITCHEN_LATITUDE. The unprefixed
+// defaults keep their historical DEVICE_PROFILE and DEVICE_ROTATION names.
+func (d *DeviceConfig) applyEnv(prefix string, errs *ConfigError) {
+	device := func(key string) string {
+		if prefix == "" {
+			return "DEVICE_" + key
+		}
+		return prefix + key
+	}
+	envFloat(prefix+"LATITUDE", &d.Location.Latitude, errs)
+	envFloat(prefix+"LONGITUDE", &d.Location.Longitude, errs)
+	envString(prefix+"TIMEZONE", &d.Location.Timezone)
+	envString(device("PROFILE"), &d.Profile)
+	if v, ok := os.LookupEnv(device("ROTATION")); ok && v != "" {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err != nil {
+			errs.addf("%s: %q is not a whole number", device("ROTATION"), v)
+		} else {
+			d.Rotation = &n
+		}
+	}
+	envInt(prefix+"GRAY_LEVELS", &d.GrayLevels, errs)
+	envString(prefix+"DITHER", &d.Dither)
+	envString(prefix+"CRON_SCHEDULE", &d.Schedule)
+	envString(prefix+"ALERT_SCHEDULE", &d.AlertSchedule)
+	envString(prefix+"UNITS", &d.Units)
+	envString(prefix+"WIND_UNIT", &d.WindUnit)
+	envString(prefix+"LOCALE", &d.Locale)
+	envString(prefix+"LAYOUT", &d.Layout)
+	envInt(prefix+"FORECAST_DAYS", &d.ForecastDays, errs)
+	envBool(prefix+"AIR_QUALITY", &d.AirQuality, errs)
+	envString(prefix+"STALE_AFTER", &d.StaleAfter)
+}
+
+func envString(key string, dst *string) {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		*dst = v
+	}
+}
+
+func envInt(key string, dst *int, errs *ConfigError) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		errs.addf("%s: %q is not a whole number", key, v)
+		return
+	}
+	*dst = n
+}
+
+func envBool(key string, dst **bool, errs *ConfigError) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(v))
+	if err != nil {
+		errs.addf("%s: %q is not true or false", key, v)
+		return
+	}
+	*dst = &b
+}
+
+func envFloat(key string, dst **float64, errs *ConfigError) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil {
+		errs.addf("%s: %q is not a number", key, v)
+		return
+	}
+	*dst = &f
+}
+
+func canonicalAlertSource(name string) string {
+	if n, ok := alertSourceNames[strings.ToLower(name)]; ok {
+		return n
+	}
+	return strings.ToLower(name)
+}
+
+func canonicalProvider(name string) string {
+	if c, ok := providerNames[strings.ToLower(name)]; ok {
+		return c
+	}
+	return strings.ToLower(name)
+}
+
+// validateURL reports the value of key unless it is empty or an http(s) URL.
+func validateURL(key, value string, errs *ConfigError) {
+	if value == "" {
+		return
+	}
+	if u, err := url.Parse(value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs.addf("%s: %q is not an http(s) URL", key, value)
+	}
+}
+
+func (c *Config) validate(errs *ConfigError) {
+	if len(c.Providers) == 0 {
+		errs.addf("providers: at least one provider is required")
+	}
+	seen := make(map[string]bool)
+	for i := range c.Providers {
+		p := &c.Providers[i]
+		key := fmt.Sprintf("providers[%d]", i)
+		name, ok := providerNames[strings.ToLower(p.Name)]
+		if !ok {
+			errs.addf("%s.name: unknown provider %q, must be one of climacell, openmeteo, tomorrow, nws, metno", key, p.Name)
+			continue
+		}
+		p.Name = name
+		if seen[name] {
+			errs.addf("%s.name: provider %q is listed twice", key, name)
+		}
+		seen[name] = true
+		if (name == "climacell" || name == "tomorrow") && p.APIKey == "" {
+			errs.addf("%s.api_key: required by %s (or set %s_API_KEY)", key, name, strings.ToUpper(name))
+		}
+		validateURL(key+".url", p.URL, errs)
+		if p.AirQualityURL != "" && name != "openmeteo" {
+			errs.addf("%s.air_quality_url: not used by %s", key, name)
+		} else {
+			validateURL(key+".air_quality_url", p.AirQualityURL, errs)
+		}
+	}
+
+	seen = make(map[string]bool)
+	for i := range c.Alerts {
+		a := &c.Alerts[i]
+		key := fmt.Sprintf("alerts[%d]", i)
+		name, ok := alertSourceNames[strings.ToLower(a.Name)]
+		if !ok {
+			errs.addf("%s.name: unknown alert source %q, must be nws or meteoalarm", key, a.Name)
+			continue
+		}
+		a.Name = name
+		if seen[name] {
+			errs.addf("%s.name: alert source %q is listed twice", key, name)
+		}
+		seen[name] = true
+		if name == "meteoalarm" && a.URL == "" {
+			errs.addf("%s.url: required by meteoalarm, the country's Atom feed (or set METEOALARM_ALERTS_URL)", key)
+		}
+		validateURL(key+".url", a.URL, errs)
+	}
+
+	switch strings.ToLower(c.Renderer) {
+	case "", "builtin", "rsvg":
+	default:
+		errs.addf("renderer: unknown renderer %q, must be builtin or rsvg", c.Renderer)
+	}
+
+	layouts, err := layoutNames(c.TemplatesDir)
+	if err != nil {
+		errs.addf("templates_dir: %v", err)
+		layouts = nil
+	}
+
+	if len(c.Devices) == 0 {
+		c.Defaults.validate("defaults", layouts, errs)
+	}
+	ids := make(map[string]bool)
+	for i, d := range c.Devices {
+		key := fmt.Sprintf("devices[%d]", i)
+		if !deviceIDRe.MatchString(d.ID) {
+			errs.addf("%s.id: invalid device id %q: use lowercase letters, digits, '-' and '_'", key, d.ID)
+		} else if ids[d.ID] {
+			errs.addf("%s.id: device %q is listed twice", key, d.ID)
+		}
+		ids[d.ID] = true
+		resolved := c.device(d)
+		resolved.validate(fmt.Sprintf("%s (%s)", key, d.ID), layouts, errs)
+	}
+}
+
+// device returns d with unset keys filled in from the defaults.
+func (c *Config) device(d DeviceConfig) DeviceConfig {
+	def := c.Defaults
+	if d.Location.Latitude == nil {
+		d.Location.Latitude = def.Location.Latitude
+	}
+	if d.Location.Longitude == nil {
+		d.Location.Longitude = def.Location.Longitude
+	}
+	if d.Location.Timezone == "" {
+		d.Location.Timezone = def.Location.Timezone
+	}
+	if d.Profile == "" {
+		d.Profile = def.Profile
+	}
+	if d.Rotation == nil {
+		d.Rotation = def.Rotation
+	}
+	if d.GrayLevels == 0 {
+		d.GrayLevels = def.GrayLevels
+	}
+	if d.Dither == "" {
+		d.Dither = def.Dither
+	}
+	if d.Schedule == "" {
+		d.Schedule = def.Schedule
+	}
+	if d.AlertSchedule == "" {
+		d.AlertSchedule = def.AlertSchedule
+	}
+	if d.Units == "" {
+		d.Units = def.Units
+	}
+	if d.WindUnit == "" {
+		d.WindUnit = def.WindUnit
+	}
+	if d.Locale == "" {
+		d.Locale = def.Locale
+	}
+	if d.Layout == "" {
+		d.Layout = def.Layout
+	}
+	if d.ForecastDays == 0 {
+		d.ForecastDays = def.ForecastDays
+	}
+	if d.AirQuality == nil {
+		d.AirQuality = def.AirQuality
+	}
+	if d.StaleAfter == "" {
+		d.StaleAfter = def.StaleAfter
+	}
+	return d
+}
+
+// validate checks d, a device with defaults applied. layouts are the
+// available layout names, or nil when they are unknown.
+func (d DeviceConfig) validate(key string, layouts []string, errs *ConfigError) {
+	if lat := d.Location.Latitude; lat == nil {
+		errs.addf("%s.location.latitude: required", key)
+	} else if *lat < -90 || *lat > 90 {
+		errs.addf("%s.location.latitude: %v is outside -90 to 90", key, *lat)
+	}
+	if lon := d.Location.Longitude; lon == nil {
+		errs.addf("%s.location.longitude: required", key)
+	} else if *lon < -180 || *lon > 180 {
+		errs.addf("%s.location.longitude: %v is outside -180 to 180", key, *lon)
+	}
+	if _, err := time.LoadLocation(d.Location.Timezone); err != nil {
+		errs.addf("%s.location.timezone: unknown timezone %q", key, d.Location.Timezone)
+	}
+
+	if _, err := lookupProfile(d.Profile, -1); err != nil {
+		errs.addf("%s.profile: %v", key, err)
+	} else if d.Rotation != nil {
+		if _, err := lookupProfile(d.Profile, *d.Rotation); err != nil {
+			errs.addf("%s.rotation: %v", key, err)
+		}
+	}
+	if d.GrayLevels != 0 {
+		if _, err := NewGrayQuantizer(d.GrayLevels, DitherNone); err != nil {
+			errs.addf("%s.gray_levels: %v", key, err)
+		}
+	}
+	if _, err := NewGrayQuantizer(2, d.Dither); err != nil {
+		errs.addf("%s.dither: %v", key, err)
+	}
+	if _, err := cron.ParseStandard(d.Schedule); err != nil {
+		errs.addf("%s.schedule: %q is not a valid cron spec: %v", key, d.Schedule, err)
+	}
+	if _, err := cron.ParseStandard(d.AlertSchedule); err != nil {
+		errs.addf("%s.alert_schedule: %q is not a valid cron spec: %v", key, d.AlertSchedule, err)
+	}
+	if _, err := lookupUnits(d.Units, ""); err != nil {
+		errs.addf("%s.units: %v", key, err)
+	} else if _, err := lookupUnits(d.Units, d.WindUnit); err != nil {
+		errs.addf("%s.wind_unit: %v", key, err)
+	}
+	if layouts != nil && !contains(layouts, d.Layout) {
+		errs.addf("%s.layout: unknown layout %q, must be one of %s", key, d.Layout, strings.Join(layouts, ", "))
+	}
+	if d.ForecastDays < minForecastDays || d.ForecastDays > maxForecastDays {
+		errs.addf("%s.forecast_days: %d is outside %d to %d", key, d.ForecastDays, minForecastDays, maxForecastDays)
+	}
+	if after, err := time.ParseDuration(d.StaleAfter); err != nil {
+		errs.addf("%s.stale_after: %q is not a duration such as 90m or 2h", key, d.StaleAfter)
+	} else if after < minStaleAfter {
+		errs.addf("%s.stale_after: %s is shorter than %s", key, d.StaleAfter, minStaleAfter)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfig writes yaml to a config file in a temporary directory and
+// returns its path.
+func writeConfig(t *testing.T, yaml string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := ioutil.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestConfigErrors checks that every problem of a configuration is reported
+// under the key it was found at.
+func TestConfigErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		yaml string
+		env  map[string]string
+		want []string
+	}{
+		{"unknown provider", "providers: [{name: darksky}]", nil,
+			[]string{`providers[0].name: unknown provider "darksky", must be one of climacell, openmeteo, tomorrow, nws, metno`}},
+		{"no providers", "providers: []", nil,
+			[]string{"providers: at least one provider is required"}},
+		{"provider twice", "providers: [{name: openmeteo}, {name: open-meteo}]", nil,
+			[]string{`providers[1].name: provider "openmeteo" is listed twice`}},
+		{"api key", "providers: [{name: tomorrow.io}]", nil,
+			[]string{"providers[0].api_key: required by tomorrow (or set TOMORROW_API_KEY)"}},
+		{"provider urls", "providers: [{name: openmeteo, url: 'ftp://example.com', air_quality_url: /air-quality}]", nil,
+			[]string{
+				`providers[0].url: "ftp://example.com" is not an http(s) URL`,
+				`providers[0].air_quality_url: "/air-quality" is not an http(s) URL`,
+			}},
+		{"air quality url", "providers: [{name: nws, air_quality_url: 'https://example.com'}]", nil,
+			[]string{"providers[0].air_quality_url: not used by nws"}},
+		{"alerts", "providers: [{name: nws}]\nalerts: [{name: meteoalarm}, {name: nws, url: 'example.com'}, {name: noaa}]", nil,
+			[]string{
+				"alerts[0].url: required by meteoalarm, the country's Atom feed (or set METEOALARM_ALERTS_URL)",
+				`alerts[1].url: "example.com" is not an http(s) URL`,
+				`alerts[2].name: unknown alert source "noaa", must be nws or meteoalarm`,
+			}},
+		{"renderer", "providers: [{name: nws}]\nrenderer: inkscape", nil,
+			[]string{`renderer: unknown renderer "inkscape", must be builtin or rsvg`}},
+		{"defaults", `providers: [{name: nws}]
+defaults:
+  location: {latitude: 91, longitude: -181, timezone: Mars/Olympus}
+  forecast_days: 8
+  stale_after: 1m
+  alert_schedule: every minute
+  layout: fancy`, nil,
+			[]string{
+				"defaults.location.latitude: 91 is outside -90 to 90",
+				"defaults.location.longitude: -181 is outside -180 to 180",
+				`defaults.location.timezone: unknown timezone "Mars/Olympus"`,
+				`defaults.alert_schedule: "every minute" is not a valid cron spec`,
+				`defaults.layout: unknown layout "fancy", must be one of default, hourly`,
+				"defaults.forecast_days: 8 is outside 3 to 7",
+				"defaults.stale_after: 1m is shorter than 5m",
+			}},
+		{"devices", "providers: [{name: nws}]\ndevices: [{id: Kitchen}, {id: office}, {id: office, units: kelvin}]", nil,
+			[]string{
+				`devices[0].id: invalid device id "Kitchen": use lowercase letters, digits, '-' and '_'`,
+				`devices[2].id: device "office" is listed twice`,
+				"devices[2] (office).units: ",
+			}},
+		{"environment", "providers: [{name: nws}]",
+			map[string]string{"LATITUDE": "north", "DEVICE_ROTATION": "quarter", "AIR_QUALITY": "maybe", "NWS_URL": "nws.example"},
+			[]string{
+				`LATITUDE: "north" is not a number`,
+				`DEVICE_ROTATION: "quarter" is not a whole number`,
+				`AIR_QUALITY: "maybe" is not true or false`,
+				`providers[0].url: "nws.example" is not an http(s) URL`,
+			}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			path := writeConfig(t, tt.yaml)
+			_, err := LoadConfig(path, true)
+			var cerr *ConfigError
+			if !errors.As(err, &cerr) {
+				t.Fatalf("err = %v, want a ConfigError", err)
+			}
+			if cerr.Source != path {
+				t.Errorf("source %q, want %q", cerr.Source, path)
+			}
+			if len(cerr.Problems) != len(tt.want) {
+				t.Errorf("problems:\n  %s\nwant %d", strings.Join(cerr.Problems, "\n  "), len(tt.want))
+			}
+			for _, want := range tt.want {
+				found := false
+				for _, p := range cerr.Problems {
+					found = found || strings.HasPrefix(p, want)
+				}
+				if !found {
+					t.Errorf("no problem %q in:\n  %s", want, strings.Join(cerr.Problems, "\n  "))
+				}
+			}
+		})
+	}
+}
+
+// TestConfigEnvOverFile checks that environment variables override the
+// matching keys of the config file and leave the rest alone.
+func TestConfigEnvOverFile(t *testing.T) {
+	path := writeConfig(t, `providers:
+  - name: tomorrow
+    api_key: file-key
+  - name: nws
+    user_agent: from the file
+defaults:
+  location: {latitude: 52.52, longitude: 13.405, timezone: Europe/Berlin}
+  units: metric
+  forecast_days: 5
+devices:
+  - id: kitchen
+    units: imperial
+  - id: office
+    locale: de
+`)
+	t.Setenv("WEATHER_PROVIDER", "nws,openmeteo,tomorrow")
+	t.Setenv("TOMORROW_API_KEY", "env-key")
+	t.Setenv("LATITUDE", "48.137")
+	t.Setenv("FORECAST_DAYS", "")
+	t.Setenv("DEVICE_KITCHEN_UNITS", "uk")
+	t.Setenv("DEVICE_OFFICE_LAYOUT", "hourly")
+
+	cfg, err := LoadConfig(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range cfg.Providers {
+		names = append(names, p.Name)
+	}
+	if got := strings.Join(names, ","); got != "nws,openmeteo,tomorrow" {
+		t.Errorf("providers %s, want the environment's order", got)
+	}
+	if p := cfg.Providers[0]; p.UserAgent != "from the file" {
+		t.Errorf("nws user agent %q, want the file's kept when reordered", p.UserAgent)
+	}
+	if p := cfg.Providers[2]; p.APIKey != "env-key" {
+		t.Errorf("tomorrow api key %q, want the environment's", p.APIKey)
+	}
+
+	d := cfg.Defaults
+	if *d.Location.Latitude != 48.137 || *d.Location.Longitude != 13.405 || d.Location.Timezone != "Europe/Berlin" {
+		t.Errorf("location %v,%v %s, want the latitude from the environment and the rest from the file",
+			*d.Location.Latitude, *d.Location.Longitude, d.Location.Timezone)
+	}
+	if d.ForecastDays != 5 || d.Units != "metric" {
+		t.Errorf("forecast days %d and units %s, want the file's: empty variables are ignored", d.ForecastDays, d.Units)
+	}
+
+	kitchen, office := cfg.device(cfg.Devices[0]), cfg.device(cfg.Devices[1])
+	if kitchen.Units != "uk" || kitchen.Layout != "default" {
+		t.Errorf("kitchen units %s and layout %s", kitchen.Units, kitchen.Layout)
+	}
+	if office.Units != "metric" || office.Locale != "de" || office.Layout != "hourly" {
+		t.Errorf("office units %s, locale %s and layout %s", office.Units, office.Locale, office.Layout)
+	}
+}
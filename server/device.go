@@ -0,0 +1,126 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron"
+	"github.com/sirupsen/logrus"
+)
+
+var deviceIDRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Device is a Kindle served by this server. Each device is generated on its
+// own schedule into its own output directory.
+type Device struct {
+	ID            string
+	Location      Location
+	Profile       DeviceProfile
+	Quantizer     *GrayQuantizer
+	CronSpec      string
+	Schedule      cron.Schedule
+	AlertSchedule cron.Schedule
+	Units         Units
+	Locale        *Locale
+	Layout        string
+	ForecastDays  int
+	AirQuality    bool
+	StaleAfter    time.Duration
+	OutDir        string
+
+	// config is the resolved configuration the device was built from.
+	config DeviceConfig
+}
+
+// BuildDevices builds the configured devices. Without any devices configured a
+// single device is built from the defaults and written to the top of
+// outDir, as before devices existed; otherwise each device is written to
+// outDir/<id>. The configuration must have been validated by LoadConfig.
+func (c *Config) BuildDevices(outDir string) ([]*Device, error) {
+	if len(c.Devices) == 0 {
+		d, err := newDevice("default", c.Defaults, outDir)
+		if err != nil {
+			return nil, err
+		}
+		return []*Device{d}, nil
+	}
+
+	var devices []*Device
+	for _, dc := range c.Devices {
+		d, err := newDevice(dc.ID, c.device(dc), filepath.Join(outDir, dc.ID))
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
+
+func newDevice(id string, dc DeviceConfig, outDir string) (*Device, error) {
+	tz, err := time.LoadLocation(dc.Location.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	rotation := -1
+	if dc.Rotation != nil {
+		rotation = *dc.Rotation
+	}
+	profile, err := lookupProfile(dc.Profile, rotation)
+	if err != nil {
+		return nil, err
+	}
+
+	levels := dc.GrayLevels
+	if levels == 0 {
+		levels = profile.GrayLevels
+	}
+	quantizer, err := NewGrayQuantizer(levels, dc.Dither)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := cron.ParseStandard(dc.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	alertSchedule, err := cron.ParseStandard(dc.AlertSchedule)
+	if err != nil {
+		return nil, err
+	}
+
+	staleAfter, err := time.ParseDuration(dc.StaleAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	units, err := lookupUnits(dc.Units, dc.WindUnit)
+	if err != nil {
+		return nil, err
+	}
+
+	locale, ok := lookupLocale(dc.Locale)
+	if !ok {
+		logrus.Warnf("device %s: no locale for %q, using English; locales are %s", id, dc.Locale, strings.Join(localeNames(), ", "))
+	}
+
+	return &Device{
+		ID:            id,
+		Location:      Location{Lat: *dc.Location.Latitude, Lon: *dc.Location.Longitude, TZ: tz},
+		Profile:       profile,
+		Quantizer:     quantizer,
+		CronSpec:      dc.Schedule,
+		Schedule:      schedule,
+		AlertSchedule: alertSchedule,
+		Units:         units,
+		Locale:        locale,
+		Layout:        dc.Layout,
+		ForecastDays:  dc.ForecastDays,
+		AirQuality:    dc.AirQuality != nil && *dc.AirQuality,
+		StaleAfter:    staleAfter,
+		OutDir:        outDir,
+		config:        dc,
+	}, nil
+}
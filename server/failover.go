@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxConsecutiveFailures is the number of failures in a row after which
+	// a provider is considered unhealthy.
+	maxConsecutiveFailures = 3
+	// unhealthyCooldown is how long an unhealthy provider is only tried
+	// after the healthy ones.
+	unhealthyCooldown = 30 * time.Minute
+)
+
+// ProviderChain is a WeatherProvider that tries an ordered list of providers
+// until one returns complete data. Providers that fail repeatedly are moved
+// behind the others for a while: they are still tried as a last resort when
+// every healthy provider fails, since a stale image is worse than a request
+// that fails again.
+type ProviderChain struct {
+	providers []WeatherProvider
+
+	mu     sync.Mutex
+	health []providerHealth
+}
+
+type providerHealth struct {
+	failures  int
+	skipUntil time.Time
+	lastErr   error
+}
+
+func NewProviderChain(providers ...WeatherProvider) *ProviderChain {
+	return &ProviderChain{
+		providers: providers,
+		health:    make([]providerHealth, len(providers)),
+	}
+}
+
+func (c *ProviderChain) Name() string {
+	names := make([]string, len(c.providers))
+	for i, p := range c.providers {
+		names[i] = p.Name()
+	}
+	return strings.Join(names, ", ")
+}
+
+func (c *ProviderChain) Forecast(loc Location, units string) (*Forecast, error) {
+	var errs chainError
+	for _, i := range c.order(time.Now()) {
+		p := c.providers[i]
+		start := time.Now()
+		forecast, err := p.Forecast(loc, units)
+		status := providerStatus(err)
+		if err == nil {
+			if err = forecast.validate(loc); err != nil {
+				status = "incomplete"
+			}
+		}
+		providerRequests.WithLabelValues(p.Name(), status).Inc()
+		providerDuration.WithLabelValues(p.Name()).Observe(time.Since(start).Seconds())
+		if err != nil {
+			logrus.Errorf("provider %s failed: %v", p.Name(), err)
+			c.recordFailure(i, err)
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+			continue
+		}
+		c.recordSuccess(i)
+		return forecast, nil
+	}
+	return nil, errs
+}
+
+// chainError holds the error of every provider of a chain that failed.
+type chainError []error
+
+func (e chainError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "all providers failed: " + strings.Join(msgs, "; ")
+}
+
+// order returns the provider indexes to try: healthy providers in their
+// configured order followed by the unhealthy ones, which are only reached
+// when every healthy provider fails.
+func (c *ProviderChain) order(now time.Time) []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var healthy, unhealthy []int
+	for i, h := range c.health {
+		if now.Before(h.skipUntil) {
+			logrus.Infof("provider %s is unhealthy until %s, trying it last", c.providers[i].Name(), h.skipUntil.Format(time.RFC3339))
+			unhealthy = append(unhealthy, i)
+			continue
+		}
+		healthy = append(healthy, i)
+	}
+	return append(healthy, unhealthy...)
+}
+
+func (c *ProviderChain) recordFailure(i int, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h := &c.health[i]
+	h.failures++
+	h.lastErr = err
+	if h.failures >= maxConsecutiveFailures {
+		h.skipUntil = time.Now().Add(unhealthyCooldown)
+	}
+}
+
+func (c *ProviderChain) recordSuccess(i int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.health[i] = providerHealth{}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// chainStub is a provider of a ProviderChain under test. It returns a
+// complete forecast from itself, one without current conditions when
+// incomplete is set, or err when it is set.
+type chainStub struct {
+	name       string
+	err        error
+	incomplete bool
+	calls      int
+}
+
+func (p *chainStub) Name() string { return p.name }
+
+func (p *chainStub) Forecast(loc Location, units string) (*Forecast, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	now := time.Now()
+	f := &Forecast{
+		Source:    p.name,
+		FetchedAt: now,
+		Units:     units,
+		Current:   Current{Time: now, Icon: "clear", Sunrise: now.Add(-time.Hour), Sunset: now.Add(time.Hour)},
+		Daily:     []Day{{Date: calendarDate(now)}},
+	}
+	if p.incomplete {
+		f.Current.Icon = ""
+	}
+	return f, nil
+}
+
+// takeCalls returns the calls of each stub and resets them.
+func takeCalls(stubs ...*chainStub) []int {
+	n := make([]int, len(stubs))
+	for i, s := range stubs {
+		n[i], s.calls = s.calls, 0
+	}
+	return n
+}
+
+func TestProviderChainOrder(t *testing.T) {
+	first, second := &chainStub{name: "order-first"}, &chainStub{name: "order-second"}
+	c := NewProviderChain(first, second)
+	if got := c.Name(); got != "order-first, order-second" {
+		t.Errorf("name %q", got)
+	}
+
+	f, err := c.Forecast(raleigh(t), UnitsMetric)
+	if err != nil || f.Source != "order-first" {
+		t.Fatalf("forecast from %v, err = %v; want the first provider's", f, err)
+	}
+	if n := takeCalls(first, second); !reflect.DeepEqual(n, []int{1, 0}) {
+		t.Errorf("calls %v, want only the first provider asked", n)
+	}
+
+	first.err = errors.New("unavailable")
+	if f, err = c.Forecast(raleigh(t), UnitsMetric); err != nil || f.Source != "order-second" {
+		t.Fatalf("forecast from %v, err = %v; want the second provider's", f, err)
+	}
+	if n := takeCalls(first, second); !reflect.DeepEqual(n, []int{1, 1}) {
+		t.Errorf("calls %v, want both providers asked", n)
+	}
+
+	second.err = errors.New("also unavailable")
+	_, err = c.Forecast(raleigh(t), UnitsMetric)
+	var errs chainError
+	if !errors.As(err, &errs) || len(errs) != 2 || !strings.Contains(err.Error(), "order-second: also unavailable") {
+		t.Errorf("err = %v, want both providers' errors", err)
+	}
+}
+
+// TestProviderChainIncomplete checks that a provider returning incomplete
+// data is failed over and counted as such.
+func TestProviderChainIncomplete(t *testing.T) {
+	first, second := &chainStub{name: "incomplete-first", incomplete: true}, &chainStub{name: "incomplete-second"}
+	c := NewProviderChain(first, second)
+	f, err := c.Forecast(raleigh(t), UnitsMetric)
+	if err != nil || f.Source != "incomplete-second" {
+		t.Fatalf("forecast from %v, err = %v; want the second provider's", f, err)
+	}
+	if n := metricValue(t, providerRequests.WithLabelValues("incomplete-first", "incomplete")); n != 1 {
+		t.Errorf("%v incomplete requests counted, want 1", n)
+	}
+	if n := metricValue(t, providerRequests.WithLabelValues("incomplete-second", "ok")); n != 1 {
+		t.Errorf("%v ok requests counted, want 1", n)
+	}
+	if h := c.health[0]; h.failures != 1 || h.lastErr == nil {
+		t.Errorf("health of the incomplete provider %+v, want a failure", h)
+	}
+}
+
+// TestProviderChainUnhealthy checks that a provider failing
+// maxConsecutiveFailures times in a row is tried after the others until its
+// cooldown ends, and still as a last resort.
+func TestProviderChainUnhealthy(t *testing.T) {
+	first, second := &chainStub{name: "unhealthy-first", err: errors.New("unavailable")}, &chainStub{name: "unhealthy-second"}
+	c := NewProviderChain(first, second)
+	for i := 0; i < maxConsecutiveFailures; i++ {
+		if n := takeCalls(first, second); i > 0 && !reflect.DeepEqual(n, []int{1, 1}) {
+			t.Fatalf("failure %d: calls %v, want the first provider asked first", i, n)
+		}
+		if _, err := c.Forecast(raleigh(t), UnitsMetric); err != nil {
+			t.Fatal(err)
+		}
+	}
+	takeCalls(first, second)
+	if until := c.health[0].skipUntil; time.Until(until) < unhealthyCooldown-time.Minute {
+		t.Fatalf("unhealthy until %s, want a cooldown", until)
+	}
+
+	if _, err := c.Forecast(raleigh(t), UnitsMetric); err != nil {
+		t.Fatal(err)
+	}
+	if n := takeCalls(first, second); !reflect.DeepEqual(n, []int{0, 1}) {
+		t.Errorf("calls %v during the cooldown, want the unhealthy provider left out while the other succeeds", n)
+	}
+
+	// the last resort
+	second.err = errors.New("also unavailable")
+	first.err = nil
+	f, err := c.Forecast(raleigh(t), UnitsMetric)
+	if err != nil || f.Source != "unhealthy-first" {
+		t.Fatalf("forecast from %v, err = %v; want the unhealthy provider's as a last resort", f, err)
+	}
+	if n := takeCalls(first, second); !reflect.DeepEqual(n, []int{1, 1}) {
+		t.Errorf("calls %v, want the unhealthy provider asked last", n)
+	}
+	if h := c.health[0]; h.failures != 0 || !h.skipUntil.IsZero() {
+		t.Errorf("health after a success %+v, want it reset", h)
+	}
+
+	// once the cooldown ends the provider is asked first again
+	second.err = nil
+	first.err = errors.New("unavailable")
+	for i := 0; i < maxConsecutiveFailures; i++ {
+		c.Forecast(raleigh(t), UnitsMetric)
+	}
+	takeCalls(first, second)
+	c.health[0].skipUntil = time.Now().Add(-time.Second)
+	first.err = nil
+	if f, err := c.Forecast(raleigh(t), UnitsMetric); err != nil || f.Source != "unhealthy-first" {
+		t.Errorf("forecast from %v, err = %v; want the first provider's after the cooldown", f, err)
+	}
+	if n := takeCalls(first, second); !reflect.DeepEqual(n, []int{1, 0}) {
+		t.Errorf("calls %v after the cooldown, want only the first provider asked", n)
+	}
+}
+
+// TestProviderChainFooter checks that the footer names the provider the
+// forecast came from rather than the chain.
+func TestProviderChainFooter(t *testing.T) {
+	c := NewProviderChain(&chainStub{name: "footer-first", err: errors.New("unavailable")}, &chainStub{name: "footer-second"})
+	f, err := c.Forecast(raleigh(t), UnitsImperial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layouts, err := loadLayouts("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	device, err := newDevice("test", defaultConfig().Defaults, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name := range builtinLayouts {
+		var svg bytes.Buffer
+		if err := layouts[name].Execute(&svg, newTemplateData(f, nil, device, f.FetchedAt)); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !strings.Contains(svg.String(), "Powered by footer-second |") {
+			t.Errorf("%s does not name the provider the forecast came from", name)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed templates/error.svg
+var errorLayout string
+
+var errorTemplate = template.Must(template.New("error").Parse(errorLayout))
+
+// Failure kinds, shown on error images and sent in the X-Weather-Error
+// header.
+const (
+	FailureProviderDown  = "provider-down"
+	FailureKeyRejected   = "key-rejected"
+	FailureRateLimited   = "rate-limited"
+	FailureConfigInvalid = "config-invalid"
+	FailureRender        = "render-failed"
+)
+
+// The cause on an error image is shown in at most errorLines lines of
+// errorLineLength characters, errorLineHeight apart from errorTop down.
+const (
+	errorLineLength = 56
+	errorLines      = 14
+	errorTop        = 360
+	errorLineHeight = 22
+)
+
+// Failure is an error image served in place of a device's image.
+type Failure struct {
+	Kind string
+	PNG  []byte
+}
+
+// failureKind classifies the error of a failed run. A chain of providers
+// that failed in different ways reports the failure that most needs the
+// user's attention.
+func failureKind(err error) string {
+	var chain chainError
+	if !errors.As(err, &chain) {
+		return FailureRender
+	}
+	kind := FailureProviderDown
+	for _, err := range chain {
+		switch statusKind(err) {
+		case FailureKeyRejected:
+			return FailureKeyRejected
+		case FailureRateLimited:
+			kind = FailureRateLimited
+		}
+	}
+	return kind
+}
+
+// statusKind returns the failure kind of an API's status code, or "" when
+// err is not a StatusError.
+func statusKind(err error) string {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return ""
+	}
+	switch statusErr.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return FailureKeyRejected
+	case http.StatusTooManyRequests:
+		return FailureRateLimited
+	}
+	return FailureProviderDown
+}
+
+// ErrorData is what the error image is executed with.
+type ErrorData struct {
+	Kind  string
+	Title string
+	// Cause is the error, broken into lines.
+	Cause       []ErrorLine
+	FailedAt    string
+	LastSuccess string
+	Labels      Labels
+}
+
+// ErrorLine is a line of text at height Y.
+type ErrorLine struct {
+	Y    int
+	Text string
+}
+
+// renderFailure draws the error image of a failure of the given kind for
+// device. lastSuccess is when the device's image was last generated, zero
+// when it never was.
+func renderFailure(r Rasterizer, device *Device, kind string, err error, now, lastSuccess time.Time) (*Failure, error) {
+	locale, tz := device.Locale, device.Location.TZ
+	data := ErrorData{
+		Kind:        kind,
+		Title:       locale.Failure(kind),
+		FailedAt:    locale.FormatDate(now.In(tz)),
+		LastSuccess: locale.Labels.Never,
+		Labels:      locale.Labels,
+	}
+	if !lastSuccess.IsZero() {
+		data.LastSuccess = locale.FormatDate(lastSuccess.In(tz))
+	}
+	for i, line := range wrapText(err.Error(), errorLineLength, errorLines) {
+		data.Cause = append(data.Cause, ErrorLine{Y: errorTop + i*errorLineHeight, Text: line})
+	}
+
+	var svg bytes.Buffer
+	if err := errorTemplate.Execute(&svg, data); err != nil {
+		return nil, fmt.Errorf("template execution error: %v", err)
+	}
+	png, err := encodeImage(r, device, svg.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return &Failure{Kind: kind, PNG: png}, nil
+}
+
+// wrapText breaks s into at most maxLines lines of at most width
+// characters, breaking between words where it can.
+func wrapText(s string, width, maxLines int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		for len([]rune(word)) > width {
+			r := []rune(word)
+			if line != "" {
+				lines, line = append(lines, line), ""
+			}
+			lines, word = append(lines, string(r[:width])), string(r[width:])
+		}
+		switch {
+		case line == "":
+			line = word
+		case len([]rune(line))+1+len([]rune(word)) <= width:
+			line += " " + word
+		default:
+			lines, line = append(lines, line), word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	if len(lines) > maxLines {
+		lines = append(lines[:maxLines-1], "…")
+	}
+	return lines
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestFailureKind(t *testing.T) {
+	status := func(provider string, code int) error {
+		return fmt.Errorf("%s: %w", provider, &StatusError{StatusCode: code, msg: fmt.Sprintf("HTTP %d", code)})
+	}
+	other := errors.New("openmeteo: connection refused")
+
+	for _, tt := range []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"not a provider error", errors.New("error writing png: disk full"), FailureRender},
+		{"key rejected", chainError{status("tomorrow", 401)}, FailureKeyRejected},
+		{"forbidden", chainError{status("nws", 403)}, FailureKeyRejected},
+		{"rate limited", chainError{status("tomorrow", 429)}, FailureRateLimited},
+		{"server error", chainError{status("metno", 503)}, FailureProviderDown},
+		{"network error", chainError{other}, FailureProviderDown},
+		// the kind that most needs the user's attention wins
+		{"rate limited and down", chainError{other, status("tomorrow", 429), status("nws", 500)}, FailureRateLimited},
+		{"key rejected and rate limited", chainError{status("openmeteo", 429), status("tomorrow", 401)}, FailureKeyRejected},
+		// as genFile wraps it
+		{"wrapped", fmt.Errorf("error getting forecast from tomorrow, nws: %w", chainError{status("tomorrow", 401)}), FailureKeyRejected},
+	} {
+		if got := failureKind(tt.err); got != tt.want {
+			t.Errorf("%s: %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
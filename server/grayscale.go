@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Dithering methods supported by GrayQuantizer.
+const (
+	DitherNone           = "none"
+	DitherFloydSteinberg = "floyd-steinberg"
+	DitherAtkinson       = "atkinson"
+	DitherOrdered        = "ordered"
+)
+
+// GrayQuantizer reduces rendered images to the gray levels of an e-ink
+// panel. The Kindle 3 and later show 16 levels; older or faster refresh
+// modes only manage 4.
+type GrayQuantizer struct {
+	levels int
+	dither string
+}
+
+func NewGrayQuantizer(levels int, dither string) (*GrayQuantizer, error) {
+	if levels < 2 || levels > 256 {
+		return nil, fmt.Errorf("gray levels must be between 2 and 256, got %d", levels)
+	}
+	dither = strings.ToLower(dither)
+	switch dither {
+	case "":
+		dither = DitherNone
+	case DitherNone, DitherFloydSteinberg, DitherAtkinson, DitherOrdered:
+	default:
+		return nil, fmt.Errorf("unknown dithering method %q", dither)
+	}
+	return &GrayQuantizer{levels: levels, dither: dither}, nil
+}
+
+// Palette returns the evenly spaced gray levels, black first.
+func (q *GrayQuantizer) Palette() color.Palette {
+	p := make(color.Palette, q.levels)
+	for i := range p {
+		p[i] = color.Gray{Y: uint8((i*255 + (q.levels-1)/2) / (q.levels - 1))}
+	}
+	return p
+}
+
+// Quantize converts img to 8-bit gray and maps it onto the palette using
+// the configured dithering.
+func (q *GrayQuantizer) Quantize(img image.Image) *image.Paletted {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	// work on luminance values so the error can be diffused
+	lum := make([]float32, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			lum[y*w+x] = float32(color.GrayModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.Gray).Y)
+		}
+	}
+
+	out := image.NewPaletted(image.Rect(0, 0, w, h), q.Palette())
+	step := float32(255) / float32(q.levels-1)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := lum[y*w+x]
+			if q.dither == DitherOrdered {
+				v += (bayer8[y%8][x%8]/64 - 0.5) * step
+			}
+			i := q.nearest(v, step)
+			out.Pix[y*out.Stride+x] = uint8(i)
+
+			e := v - float32(i)*step
+			switch q.dither {
+			case DitherFloydSteinberg:
+				diffuse(lum, w, h, x, y, e, floydSteinberg)
+			case DitherAtkinson:
+				diffuse(lum, w, h, x, y, e, atkinson)
+			}
+		}
+	}
+	return out
+}
+
+func (q *GrayQuantizer) nearest(v, step float32) int {
+	i := int(v/step + 0.5)
+	if i < 0 {
+		return 0
+	}
+	if i >= q.levels {
+		return q.levels - 1
+	}
+	return i
+}
+
+type diffusion struct {
+	dx, dy int
+	weight float32
+}
+
+var (
+	floydSteinberg = []diffusion{{1, 0, 7.0 / 16}, {-1, 1, 3.0 / 16}, {0, 1, 5.0 / 16}, {1, 1, 1.0 / 16}}
+	// atkinson only diffuses 3/4 of the error, which keeps large flat areas
+	// clean at the cost of detail in highlights and shadows
+	atkinson = []diffusion{{1, 0, 1.0 / 8}, {2, 0, 1.0 / 8}, {-1, 1, 1.0 / 8}, {0, 1, 1.0 / 8}, {1, 1, 1.0 / 8}, {0, 2, 1.0 / 8}}
+
+	bayer8 = [8][8]float32{
+		{0, 32, 8, 40, 2, 34, 10, 42},
+		{48, 16, 56, 24, 50, 18, 58, 26},
+		{12, 44, 4, 36, 14, 46, 6, 38},
+		{60, 28, 52, 20, 62, 30, 54, 22},
+		{3, 35, 11, 43, 1, 33, 9, 41},
+		{51, 19, 59, 27, 49, 17, 57, 25},
+		{15, 47, 7, 39, 13, 45, 5, 37},
+		{63, 31, 55, 23, 61, 29, 53, 21},
+	}
+)
+
+func diffuse(lum []float32, w, h, x, y int, e float32, kernel []diffusion) {
+	for _, d := range kernel {
+		nx, ny := x+d.dx, y+d.dy
+		if nx < 0 || nx >= w || ny >= h {
+			continue
+		}
+		lum[ny*w+nx] += e * d.weight
+	}
+}
+
+// EncodePNG quantises img and encodes it as a palette-indexed PNG, which
+// eips -g displays without further conversion.
+func (q *GrayQuantizer) EncodePNG(img image.Image) ([]byte, error) {
+	logrus.Infof("quantising to %d gray levels with %s dithering", q.levels, q.dither)
+	paletted := q.Quantize(img)
+
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := enc.Encode(&buf, paletted); err != nil {
+		return nil, fmt.Errorf("error encoding png: %v", err)
+	}
+	return buf.Bytes(), nil
+}
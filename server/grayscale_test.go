@@ -0,0 +1,156 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// grayImage returns a w×h image with the gray of each pixel set by gray.
+func grayImage(w, h int, gray func(x, y int) uint8) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetGray(x, y, color.Gray{Y: gray(x, y)})
+		}
+	}
+	return img
+}
+
+func TestNewGrayQuantizer(t *testing.T) {
+	for _, tt := range []struct {
+		levels int
+		dither string
+		ok     bool
+	}{
+		{16, "", true},
+		{2, "Floyd-Steinberg", true},
+		{256, DitherOrdered, true},
+		{1, DitherNone, false},
+		{257, DitherNone, false},
+		{16, "bayer", false},
+	} {
+		_, err := NewGrayQuantizer(tt.levels, tt.dither)
+		if (err == nil) != tt.ok {
+			t.Errorf("%d levels, %q dithering: err = %v", tt.levels, tt.dither, err)
+		}
+	}
+}
+
+func TestGrayQuantizerPalette(t *testing.T) {
+	for levels, want := range map[int][]uint8{
+		2:  {0, 255},
+		4:  {0, 85, 170, 255},
+		16: {0, 17, 34, 51, 68, 85, 102, 119, 136, 153, 170, 187, 204, 221, 238, 255},
+	} {
+		q, err := NewGrayQuantizer(levels, DitherNone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := q.Palette()
+		if len(p) != levels {
+			t.Fatalf("%d levels: palette of %d", levels, len(p))
+		}
+		for i, c := range p {
+			if c.(color.Gray).Y != want[i] {
+				t.Errorf("%d levels: level %d is %v, want %d", levels, i, c, want[i])
+			}
+		}
+	}
+}
+
+// TestGrayQuantizerLevels checks that without dithering every gray is mapped
+// to the nearest level.
+func TestGrayQuantizerLevels(t *testing.T) {
+	q, err := NewGrayQuantizer(4, DitherNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a gradient of every gray, drawn off the origin
+	img := grayImage(266, 1, func(x, _ int) uint8 { return uint8(x - 10) }).SubImage(image.Rect(10, 0, 266, 1))
+	out := q.Quantize(img)
+	if b := out.Bounds(); b != image.Rect(0, 0, 256, 1) {
+		t.Fatalf("bounds %v", b)
+	}
+	for v := 0; v < 256; v++ {
+		want := 0
+		switch {
+		case v > 212: // halfway between 170 and 255
+			want = 3
+		case v > 127:
+			want = 2
+		case v > 42:
+			want = 1
+		}
+		if got := int(out.ColorIndexAt(v, 0)); got != want {
+			t.Errorf("gray %d is level %d, want %d", v, got, want)
+		}
+	}
+}
+
+// TestGrayQuantizerDither checks that dithering only draws with the palette,
+// keeps the mean gray of flat areas and leaves grays already in the palette
+// alone.
+func TestGrayQuantizerDither(t *testing.T) {
+	for _, dither := range []string{DitherFloydSteinberg, DitherAtkinson, DitherOrdered} {
+		q, err := NewGrayQuantizer(4, dither)
+		if err != nil {
+			t.Fatal(err)
+		}
+		palette := q.Palette()
+
+		gradient := q.Quantize(grayImage(256, 32, func(x, _ int) uint8 { return uint8(x) }))
+		if len(gradient.Palette) != len(palette) {
+			t.Errorf("%s: palette of %d, want %d", dither, len(gradient.Palette), len(palette))
+		}
+		for i, c := range gradient.Palette {
+			if c != palette[i] {
+				t.Errorf("%s: palette %v, want %v", dither, gradient.Palette, palette)
+				break
+			}
+		}
+		for _, i := range gradient.Pix {
+			if int(i) >= len(palette) {
+				t.Fatalf("%s: index %d outside the palette", dither, i)
+			}
+		}
+		if gradient.ColorIndexAt(0, 0) != 0 || gradient.ColorIndexAt(255, 31) != 3 {
+			t.Errorf("%s: black and white are not kept", dither)
+		}
+
+		for _, gray := range []uint8{60, 100, 200} {
+			flat := q.Quantize(grayImage(64, 64, func(_, _ int) uint8 { return gray }))
+			sum, used := 0.0, make(map[uint8]bool)
+			for _, i := range flat.Pix {
+				sum += float64(palette[i].(color.Gray).Y)
+				used[i] = true
+			}
+			// atkinson drops a quarter of the error, so its mean drifts
+			// towards the nearest level
+			tolerance := 4.0
+			if dither == DitherAtkinson {
+				tolerance = 12
+			}
+			if mean := sum / float64(len(flat.Pix)); math.Abs(mean-float64(gray)) > tolerance {
+				t.Errorf("%s: gray %d is drawn as %.1f on average", dither, gray, mean)
+			}
+			if len(used) != 2 {
+				t.Errorf("%s: gray %d is drawn with %d levels, want the 2 around it", dither, gray, len(used))
+			}
+		}
+	}
+
+	for _, dither := range []string{DitherFloydSteinberg, DitherAtkinson} {
+		q, err := NewGrayQuantizer(4, dither)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, i := range q.Quantize(grayImage(16, 16, func(_, _ int) uint8 { return 170 })).Pix {
+			if i != 2 {
+				t.Errorf("%s: gray 170 drawn with level %d, want only 2", dither, i)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,480 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Labels are the static texts of the image.
+type Labels struct {
+	Currently    string
+	High         string
+	Low          string
+	Location     string
+	PoweredBy    string
+	ForecastAsOf string
+	Until        string
+	AirQuality   string
+	TreePollen   string
+	GrassPollen  string
+	WeedPollen   string
+	// StaleMinutes and StaleHours say how old the data is; %d is replaced
+	// by the number of minutes or hours.
+	StaleMinutes string
+	StaleHours   string
+	FailedAt     string
+	LastSuccess  string
+	Never        string
+}
+
+// Locale holds the texts and formats of one language. Empty fields fall back
+// to English, so a new locale can start with only some of them translated.
+type Locale struct {
+	Name string
+	// Weekdays are indexed by time.Weekday, starting with Sunday.
+	Weekdays [7]string
+	// Months are the abbreviated month names, starting with January.
+	Months [12]string
+	// DateFormat is a time layout for the forecast date. "Monday" and "Jan"
+	// are replaced by the localized weekday and month names.
+	DateFormat string
+	// TimeFormat is a time layout for sunrise and sunset.
+	TimeFormat string
+	// HourFormat is a time layout for the hours of the hourly chart.
+	HourFormat string
+	// Decimal is the decimal separator of the values shown.
+	Decimal string
+	// MoonPhases maps moon phase ids to their names.
+	MoonPhases map[string]string
+	// AQICategories name the EPA air quality categories, from good to
+	// hazardous.
+	AQICategories [6]string
+	// PollenLevels name the pollen levels, from none to very high.
+	PollenLevels [6]string
+	// Failures maps failure kinds to the titles of error images.
+	Failures map[string]string
+	Labels   Labels
+}
+
+// locales is the message catalogue. Add a locale by adding an entry here.
+var locales = map[string]*Locale{
+	"en": {
+		Name:          "en",
+		Weekdays:      [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		Months:        [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		DateFormat:    "Monday Jan 2, 15:04 MST",
+		TimeFormat:    time.Kitchen,
+		HourFormat:    "3PM",
+		Decimal:       ".",
+		AQICategories: [6]string{"Good", "Moderate", "Unhealthy for sensitive groups", "Unhealthy", "Very unhealthy", "Hazardous"},
+		PollenLevels:  [6]string{"None", "Very low", "Low", "Medium", "High", "Very high"},
+		Failures: map[string]string{
+			FailureProviderDown:  "Weather service unavailable",
+			FailureKeyRejected:   "API key rejected",
+			FailureRateLimited:   "API rate limit reached",
+			FailureConfigInvalid: "Invalid configuration",
+			FailureRender:        "Image could not be drawn",
+		},
+		Labels: Labels{
+			Currently:    "Currently:",
+			High:         "High:",
+			Low:          "Low:",
+			Location:     "Location:",
+			PoweredBy:    "Powered by",
+			ForecastAsOf: "Forecast as of:",
+			Until:        "until",
+			AirQuality:   "Air quality:",
+			TreePollen:   "Tree pollen",
+			GrassPollen:  "Grass pollen",
+			WeedPollen:   "Weed pollen",
+			StaleMinutes: "Data is %d minutes old",
+			StaleHours:   "Data is %d hours old",
+			FailedAt:     "Failed at:",
+			LastSuccess:  "Last update:",
+			Never:        "never",
+		},
+	},
+	"de": {
+		Name:       "de",
+		Weekdays:   [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		Months:     [12]string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sep.", "Okt.", "Nov.", "Dez."},
+		DateFormat: "Monday, 2. Jan, 15:04 MST",
+		TimeFormat: "15:04",
+		HourFormat: "15",
+		Decimal:    ",",
+		MoonPhases: map[string]string{
+			"new":             "Neumond",
+			"waxing_crescent": "Zunehmende Sichel",
+			"first_quarter":   "Erstes Viertel",
+			"waxing_gibbous":  "Zunehmender Mond",
+			"full":            "Vollmond",
+			"waning_gibbous":  "Abnehmender Mond",
+			"last_quarter":    "Letztes Viertel",
+			"waning_crescent": "Abnehmende Sichel",
+		},
+		AQICategories: [6]string{"Gut", "Mäßig", "Ungesund für Empfindliche", "Ungesund", "Sehr ungesund", "Gefährlich"},
+		PollenLevels:  [6]string{"Keine", "Sehr gering", "Gering", "Mittel", "Hoch", "Sehr hoch"},
+		Failures: map[string]string{
+			FailureProviderDown:  "Wetterdienst nicht erreichbar",
+			FailureKeyRejected:   "API-Schlüssel abgelehnt",
+			FailureRateLimited:   "API-Anfragelimit erreicht",
+			FailureConfigInvalid: "Ungültige Konfiguration",
+			FailureRender:        "Bild konnte nicht erstellt werden",
+		},
+		Labels: Labels{
+			Currently:    "Aktuell:",
+			High:         "Max:",
+			Low:          "Min:",
+			Location:     "Standort:",
+			PoweredBy:    "Daten von",
+			ForecastAsOf: "Vorhersage vom:",
+			Until:        "bis",
+			AirQuality:   "Luftqualität:",
+			TreePollen:   "Baumpollen",
+			GrassPollen:  "Gräserpollen",
+			WeedPollen:   "Kräuterpollen",
+			StaleMinutes: "Daten sind %d Minuten alt",
+			StaleHours:   "Daten sind %d Stunden alt",
+			FailedAt:     "Fehler am:",
+			LastSuccess:  "Letzte Aktualisierung:",
+			Never:        "nie",
+		},
+	},
+	"fr": {
+		Name:       "fr",
+		Weekdays:   [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		Months:     [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		DateFormat: "Monday 2 Jan, 15:04 MST",
+		TimeFormat: "15:04",
+		HourFormat: "15",
+		Decimal:    ",",
+		MoonPhases: map[string]string{
+			"new":             "Nouvelle lune",
+			"waxing_crescent": "Premier croissant",
+			"first_quarter":   "Premier quartier",
+			"waxing_gibbous":  "Gibbeuse croissante",
+			"full":            "Pleine lune",
+			"waning_gibbous":  "Gibbeuse décroissante",
+			"last_quarter":    "Dernier quartier",
+			"waning_crescent": "Dernier croissant",
+		},
+		AQICategories: [6]string{"Bonne", "Moyenne", "Mauvaise pour les sensibles", "Mauvaise", "Très mauvaise", "Dangereuse"},
+		PollenLevels:  [6]string{"Nul", "Très faible", "Faible", "Moyen", "Élevé", "Très élevé"},
+		Failures: map[string]string{
+			FailureProviderDown:  "Service météo indisponible",
+			FailureKeyRejected:   "Clé API refusée",
+			FailureRateLimited:   "Limite de requêtes API atteinte",
+			FailureConfigInvalid: "Configuration invalide",
+			FailureRender:        "Impossible de créer l'image",
+		},
+		Labels: Labels{
+			Currently:    "Actuel :",
+			High:         "Max :",
+			Low:          "Min :",
+			Location:     "Position :",
+			PoweredBy:    "Données",
+			ForecastAsOf: "Prévision du :",
+			Until:        "jusqu'à",
+			AirQuality:   "Qualité de l'air :",
+			TreePollen:   "Arbres",
+			GrassPollen:  "Graminées",
+			WeedPollen:   "Herbacées",
+			StaleMinutes: "Données vieilles de %d minutes",
+			StaleHours:   "Données vieilles de %d heures",
+			FailedAt:     "Échec le :",
+			LastSuccess:  "Dernière mise à jour :",
+			Never:        "jamais",
+		},
+	},
+	"es": {
+		Name:       "es",
+		Weekdays:   [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		Months:     [12]string{"ene.", "feb.", "mar.", "abr.", "may.", "jun.", "jul.", "ago.", "sept.", "oct.", "nov.", "dic."},
+		DateFormat: "Monday, 2 Jan, 15:04 MST",
+		TimeFormat: "15:04",
+		HourFormat: "15",
+		Decimal:    ",",
+		MoonPhases: map[string]string{
+			"new":             "Luna nueva",
+			"waxing_crescent": "Luna creciente",
+			"first_quarter":   "Cuarto creciente",
+			"waxing_gibbous":  "Gibosa creciente",
+			"full":            "Luna llena",
+			"waning_gibbous":  "Gibosa menguante",
+			"last_quarter":    "Cuarto menguante",
+			"waning_crescent": "Luna menguante",
+		},
+		AQICategories: [6]string{"Buena", "Moderada", "Dañina para sensibles", "Dañina", "Muy dañina", "Peligrosa"},
+		PollenLevels:  [6]string{"Nulo", "Muy bajo", "Bajo", "Medio", "Alto", "Muy alto"},
+		Failures: map[string]string{
+			FailureProviderDown:  "Servicio meteorológico no disponible",
+			FailureKeyRejected:   "Clave de API rechazada",
+			FailureRateLimited:   "Límite de solicitudes de la API alcanzado",
+			FailureConfigInvalid: "Configuración no válida",
+			FailureRender:        "No se pudo generar la imagen",
+		},
+		Labels: Labels{
+			Currently:    "Ahora:",
+			High:         "Máx:",
+			Low:          "Mín:",
+			Location:     "Ubicación:",
+			PoweredBy:    "Datos de",
+			ForecastAsOf: "Pronóstico del:",
+			Until:        "hasta",
+			AirQuality:   "Calidad del aire:",
+			TreePollen:   "Árboles",
+			GrassPollen:  "Gramíneas",
+			WeedPollen:   "Malezas",
+			StaleMinutes: "Datos de hace %d minutos",
+			StaleHours:   "Datos de hace %d horas",
+			FailedAt:     "Error el:",
+			LastSuccess:  "Última actualización:",
+			Never:        "nunca",
+		},
+	},
+	"nl": {
+		Name:       "nl",
+		Weekdays:   [7]string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+		Months:     [12]string{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+		DateFormat: "Monday 2 Jan, 15:04 MST",
+		TimeFormat: "15:04",
+		HourFormat: "15",
+		Decimal:    ",",
+		MoonPhases: map[string]string{
+			"new":             "Nieuwe maan",
+			"waxing_crescent": "Wassende sikkel",
+			"first_quarter":   "Eerste kwartier",
+			"waxing_gibbous":  "Wassende maan",
+			"full":            "Volle maan",
+			"waning_gibbous":  "Afnemende maan",
+			"last_quarter":    "Laatste kwartier",
+			"waning_crescent": "Afnemende sikkel",
+		},
+		AQICategories: [6]string{"Goed", "Matig", "Ongezond voor gevoeligen", "Ongezond", "Zeer ongezond", "Gevaarlijk"},
+		PollenLevels:  [6]string{"Geen", "Zeer laag", "Laag", "Matig", "Hoog", "Zeer hoog"},
+		Failures: map[string]string{
+			FailureProviderDown:  "Weerdienst niet beschikbaar",
+			FailureKeyRejected:   "API-sleutel geweigerd",
+			FailureRateLimited:   "API-limiet bereikt",
+			FailureConfigInvalid: "Ongeldige configuratie",
+			FailureRender:        "Afbeelding kon niet worden gemaakt",
+		},
+		Labels: Labels{
+			Currently:    "Nu:",
+			High:         "Max:",
+			Low:          "Min:",
+			Location:     "Locatie:",
+			PoweredBy:    "Gegevens van",
+			ForecastAsOf: "Verwachting van:",
+			Until:        "tot",
+			AirQuality:   "Luchtkwaliteit:",
+			TreePollen:   "Bomen",
+			GrassPollen:  "Grassen",
+			WeedPollen:   "Kruiden",
+			StaleMinutes: "Gegevens zijn %d minuten oud",
+			StaleHours:   "Gegevens zijn %d uur oud",
+			FailedAt:     "Mislukt op:",
+			LastSuccess:  "Laatst bijgewerkt:",
+			Never:        "nooit",
+		},
+	},
+	"ja": {
+		Name:       "ja",
+		Weekdays:   [7]string{"日曜日", "月曜日", "火曜日", "水曜日", "木曜日", "金曜日", "土曜日"},
+		Months:     [12]string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+		DateFormat: "Jan2日 (Monday) 15:04 MST",
+		TimeFormat: "15:04",
+		HourFormat: "15",
+		MoonPhases: map[string]string{
+			"new":             "新月",
+			"waxing_crescent": "三日月",
+			"first_quarter":   "上弦の月",
+			"waxing_gibbous":  "十三夜月",
+			"full":            "満月",
+			"waning_gibbous":  "寝待月",
+			"last_quarter":    "下弦の月",
+			"waning_crescent": "有明月",
+		},
+		AQICategories: [6]string{"良い", "普通", "敏感な人に不健康", "不健康", "非常に不健康", "危険"},
+		PollenLevels:  [6]string{"なし", "非常に少ない", "少ない", "やや多い", "多い", "非常に多い"},
+		Failures: map[string]string{
+			FailureProviderDown:  "気象サービスに接続できません",
+			FailureKeyRejected:   "APIキーが拒否されました",
+			FailureRateLimited:   "APIのリクエスト上限に達しました",
+			FailureConfigInvalid: "設定が無効です",
+			FailureRender:        "画像を作成できませんでした",
+		},
+		Labels: Labels{
+			Currently:    "現在:",
+			High:         "最高:",
+			Low:          "最低:",
+			Location:     "位置:",
+			PoweredBy:    "提供:",
+			ForecastAsOf: "予報時刻:",
+			Until:        "終了予定",
+			AirQuality:   "大気質:",
+			TreePollen:   "樹木花粉",
+			GrassPollen:  "イネ科花粉",
+			WeedPollen:   "雑草花粉",
+			StaleMinutes: "データは%d分前のものです",
+			StaleHours:   "データは%d時間前のものです",
+			FailedAt:     "失敗時刻:",
+			LastSuccess:  "最終更新:",
+			Never:        "なし",
+		},
+	},
+}
+
+// lookupLocale returns the locale for a tag such as "de" or "de-AT",
+// filling in untranslated fields from English. A language without a locale
+// gets English, and ok is false.
+func lookupLocale(tag string) (l *Locale, ok bool) {
+	tag = strings.ToLower(strings.Replace(tag, "_", "-", -1))
+	l, ok = locales[tag]
+	if !ok {
+		l, ok = locales[strings.SplitN(tag, "-", 2)[0]]
+	}
+	if !ok {
+		return locales["en"], false
+	}
+	return l.withFallback(locales["en"]), true
+}
+
+// localeNames lists the tags of the locales.
+func localeNames() []string {
+	names := make([]string, 0, len(locales))
+	for n := range locales {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (l *Locale) withFallback(en *Locale) *Locale {
+	merged := *l
+	for i := range merged.Weekdays {
+		if merged.Weekdays[i] == "" {
+			merged.Weekdays[i] = en.Weekdays[i]
+		}
+	}
+	for i := range merged.Months {
+		if merged.Months[i] == "" {
+			merged.Months[i] = en.Months[i]
+		}
+	}
+	if merged.DateFormat == "" {
+		merged.DateFormat = en.DateFormat
+	}
+	if merged.TimeFormat == "" {
+		merged.TimeFormat = en.TimeFormat
+	}
+	if merged.HourFormat == "" {
+		merged.HourFormat = en.HourFormat
+	}
+	if merged.Decimal == "" {
+		merged.Decimal = en.Decimal
+	}
+	for i := range merged.AQICategories {
+		if merged.AQICategories[i] == "" {
+			merged.AQICategories[i] = en.AQICategories[i]
+		}
+	}
+	for i := range merged.PollenLevels {
+		if merged.PollenLevels[i] == "" {
+			merged.PollenLevels[i] = en.PollenLevels[i]
+		}
+	}
+	fallback := func(s *string, def string) {
+		if *s == "" {
+			*s = def
+		}
+	}
+	fallback(&merged.Labels.Currently, en.Labels.Currently)
+	fallback(&merged.Labels.High, en.Labels.High)
+	fallback(&merged.Labels.Low, en.Labels.Low)
+	fallback(&merged.Labels.Location, en.Labels.Location)
+	fallback(&merged.Labels.PoweredBy, en.Labels.PoweredBy)
+	fallback(&merged.Labels.ForecastAsOf, en.Labels.ForecastAsOf)
+	fallback(&merged.Labels.Until, en.Labels.Until)
+	fallback(&merged.Labels.AirQuality, en.Labels.AirQuality)
+	fallback(&merged.Labels.TreePollen, en.Labels.TreePollen)
+	fallback(&merged.Labels.GrassPollen, en.Labels.GrassPollen)
+	fallback(&merged.Labels.WeedPollen, en.Labels.WeedPollen)
+	fallback(&merged.Labels.StaleMinutes, en.Labels.StaleMinutes)
+	fallback(&merged.Labels.StaleHours, en.Labels.StaleHours)
+	fallback(&merged.Labels.FailedAt, en.Labels.FailedAt)
+	fallback(&merged.Labels.LastSuccess, en.Labels.LastSuccess)
+	fallback(&merged.Labels.Never, en.Labels.Never)
+	return &merged
+}
+
+func (l *Locale) Weekday(d time.Weekday) string {
+	return l.Weekdays[d]
+}
+
+// MoonPhase names a moon phase id, title-casing the id when the locale has
+// no name for it.
+func (l *Locale) MoonPhase(id string) string {
+	if name, ok := l.MoonPhases[id]; ok {
+		return name
+	}
+	return getMoonPhase(id)
+}
+
+// AQICategory names the EPA category of an air quality index.
+func (l *Locale) AQICategory(aqi int) string {
+	return l.AQICategories[aqiCategory(aqi)]
+}
+
+// PollenLevel names a pollen level from 0 to 5.
+func (l *Locale) PollenLevel(level int) string {
+	if level < 0 || level >= len(l.PollenLevels) {
+		return ""
+	}
+	return l.PollenLevels[level]
+}
+
+// Failure titles the error image of a failure kind.
+func (l *Locale) Failure(kind string) string {
+	if title, ok := l.Failures[kind]; ok {
+		return title
+	}
+	return locales["en"].Failures[kind]
+}
+
+// FormatAge says how old data of age d is, in minutes under two hours and
+// in hours above.
+func (l *Locale) FormatAge(d time.Duration) string {
+	if d < 2*time.Hour {
+		return fmt.Sprintf(l.Labels.StaleMinutes, int(d.Minutes()))
+	}
+	return fmt.Sprintf(l.Labels.StaleHours, int(d.Hours()))
+}
+
+// FormatNumber swaps the decimal point of a formatted number for the
+// locale's separator.
+func (l *Locale) FormatNumber(s string) string {
+	return strings.Replace(s, ".", l.Decimal, 1)
+}
+
+// FormatDate formats t with DateFormat.
+func (l *Locale) FormatDate(t time.Time) string {
+	return l.format(t, l.DateFormat)
+}
+
+// FormatTime formats t with TimeFormat.
+func (l *Locale) FormatTime(t time.Time) string {
+	return l.format(t, l.TimeFormat)
+}
+
+// format formats t with layout after swapping the English weekday and month
+// names for placeholders the time package leaves alone.
+func (l *Locale) format(t time.Time, layout string) string {
+	layout = strings.Replace(layout, "Monday", "\x00", -1)
+	layout = strings.Replace(layout, "Jan", "\x01", -1)
+	s := t.Format(layout)
+	s = strings.Replace(s, "\x00", l.Weekday(t.Weekday()), -1)
+	return strings.Replace(s, "\x01", l.Months[t.Month()-1], -1)
+}
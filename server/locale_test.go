@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestLocales executes a layout for each shipped locale, so the template
+// functions are checked along with the catalogue.
+func TestLocales(t *testing.T) {
+	layout, err := parseLayout("test", `{{weekday .Now}}|{{date .Now}}|{{time .Now}}|{{format "Monday" .Now}}|`+
+		`{{pressure 29.65}}|{{precip 0.58}}|{{temp 71.6}}|{{moon "full"}}|{{.Labels.Currently}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a Friday
+	now := time.Date(2025, 10, 17, 14, 5, 0, 0, time.UTC)
+
+	for _, tt := range []struct {
+		locale string
+		want   string
+		hour   string
+	}{
+		{"en", "Friday|Friday Oct 17, 14:05 UTC|2:05PM|Friday|29.65|0.58|72|Full|Currently:", "2PM"},
+		{"de", "Freitag|Freitag, 17. Okt., 14:05 UTC|14:05|Friday|29,65|0,58|72|Vollmond|Aktuell:", "14"},
+		{"fr", "vendredi|vendredi 17 oct., 14:05 UTC|14:05|Friday|29,65|0,58|72|Pleine lune|Actuel :", "14"},
+		{"es", "viernes|viernes, 17 oct., 14:05 UTC|14:05|Friday|29,65|0,58|72|Luna llena|Ahora:", "14"},
+		{"nl", "vrijdag|vrijdag 17 okt, 14:05 UTC|14:05|Friday|29,65|0,58|72|Volle maan|Nu:", "14"},
+		{"ja", "金曜日|10月17日 (金曜日) 14:05 UTC|14:05|Friday|29.65|0.58|72|満月|現在:", "14"},
+		// region tags use their language
+		{"de-AT", "Freitag|Freitag, 17. Okt., 14:05 UTC|14:05|Friday|29,65|0,58|72|Vollmond|Aktuell:", "14"},
+		{"pt_BR", "Friday|Friday Oct 17, 14:05 UTC|2:05PM|Friday|29.65|0.58|72|Full|Currently:", "2PM"},
+	} {
+		dc := defaultConfig().Defaults
+		dc.Locale = tt.locale
+		device, err := newDevice("test", dc, "")
+		if err != nil {
+			t.Fatalf("%s: %v", tt.locale, err)
+		}
+		forecast := &Forecast{FetchedAt: now, Units: UnitsImperial}
+		var out bytes.Buffer
+		if err := layout.Execute(&out, newTemplateData(forecast, nil, device, now)); err != nil {
+			t.Fatalf("%s: %v", tt.locale, err)
+		}
+		if got := out.String(); got != tt.want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.locale, got, tt.want)
+		}
+		if got := device.Locale.format(now, device.Locale.HourFormat); got != tt.hour {
+			t.Errorf("%s: hour = %q, want %q", tt.locale, got, tt.hour)
+		}
+	}
+}
+
+func TestLookupLocale(t *testing.T) {
+	for _, tag := range []string{"en", "de", "fr", "es", "nl", "ja", "DE_at"} {
+		if _, ok := lookupLocale(tag); !ok {
+			t.Errorf("no locale for %q", tag)
+		}
+	}
+	l, ok := lookupLocale("xx")
+	if ok || l != locales["en"] {
+		t.Errorf("lookupLocale(xx) = %s, %v, want English", l.Name, ok)
+	}
+}
+
+// TestLocalesComplete checks that every shipped locale translates every
+// weekday, month and label, so none shows English by accident.
+func TestLocalesComplete(t *testing.T) {
+	for name, l := range locales {
+		for i, d := range l.Weekdays {
+			if d == "" {
+				t.Errorf("%s: weekday %d is missing", name, i)
+			}
+		}
+		for i, m := range l.Months {
+			if m == "" {
+				t.Errorf("%s: month %d is missing", name, i+1)
+			}
+		}
+		labels := reflect.ValueOf(l.Labels)
+		for i := 0; i < labels.NumField(); i++ {
+			if labels.Field(i).String() == "" {
+				t.Errorf("%s: label %s is missing", name, labels.Type().Field(i).Name)
+			}
+		}
+		if name != "en" && len(l.MoonPhases) != len(locales["de"].MoonPhases) {
+			t.Errorf("%s: %d moon phases, want %d", name, len(l.MoonPhases), len(locales["de"].MoonPhases))
+		}
+	}
+}
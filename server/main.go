@@ -1,28 +1,30 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"image"
+	"io/ioutil"
 	"net/http"
 	"os"
-	"os/exec"
-	"strconv"
+	"path/filepath"
+	"reflect"
 	"strings"
-	"text/template"
+	"sync"
+	"sync/atomic"
 
 	"time"
 	_ "time/tzdata"
 
-	"github.com/andyhaskell/climacell-go"
-	"github.com/robfig/cron"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 )
 
 var (
-	location    *time.Location
 	defaultCron = "*/5 * * * *"
 	// extra          = "pm25,pm10,o3,no2,co,so2,epa_aqi,epa_primary_pollutant,epa_health_concern,pollen_tree,pollen_weed,pollen_grass,road_risk_score,road_risk,road_risk_confidence,road_risk_conditions,fire_index,hail_binary"
 
-	realTimeFields = "precipitation,precipitation_type,temp,feels_like,dewpoint,wind_speed,wind_gust,baro_pressure,visibility,humidity,wind_direction,sunrise,sunset,cloud_cover,cloud_ceiling,cloud_base,surface_shortwave_radiation,moon_phase,weather_code"
+	realTimeFields = "pm25,epa_aqi,epa_primary_pollutant,precipitation,precipitation_type,temp,feels_like,dewpoint,wind_speed,wind_gust,baro_pressure,visibility,humidity,wind_direction,sunrise,sunset,cloud_cover,cloud_ceiling,cloud_base,surface_shortwave_radiation,moon_phase,weather_code"
 	hourlyFields   = "precipitation,precipitation_type,precipitation_probability,temp,feels_like,dewpoint,wind_speed,wind_gust,baro_pressure,visibility,humidity,wind_direction,sunrise,sunset,cloud_cover,cloud_ceiling,cloud_base,surface_shortwave_radiation,moon_phase,weather_code"
 	dailyFields    = "precipitation,precipitation_accumulation,temp,feels_like,wind_speed,baro_pressure,visibility,humidity,wind_direction,sunrise,sunset,moon_phase,weather_code,dewpoint"
 
@@ -38,17 +40,6 @@ func getEnvString(key string, defaultVal string) string {
 	return valueStr
 }
 
-func getEnvAsFloat64(key string, defaultVal float64) float64 {
-	valueStr, exists := os.LookupEnv(key)
-	if !exists {
-		logrus.Infof("env variable %s not defined. Using default: %d", key, defaultVal)
-	}
-	if value, err := strconv.ParseFloat(valueStr, 64); err == nil {
-		return value
-	}
-	return defaultVal
-}
-
 func getDayOrNight(current, rise, set time.Time) string {
 	if rise.Before(current) && current.Before(set) {
 		return "day"
@@ -70,448 +61,328 @@ func getMoonPhase(i string) string {
 	return strings.Title(s)
 }
 
-func validateCronSpec(spec string) cron.Schedule {
-	s, err := cron.ParseStandard(spec)
-	if err != nil {
-		logrus.Infof("using default schedule `%s`. Cron spec schedule `%s` not valid: %v", defaultCron, spec, err)
-		s, err = cron.ParseStandard(defaultCron)
-		if err != nil {
-			logrus.Fatalf("failed to parse default schedule: %v", err)
-		}
-	}
-	return s
-}
-
 func init() {
 	logrus.SetFormatter(&logrus.TextFormatter{})
-	tz := getEnvString("TIMEZONE", "UTC")
-
-	var err error
-	location, err = time.LoadLocation(tz)
-	if err != nil {
-		logrus.Infof("defaulting to UTC: timezone %s not found: %v", tz, err)
-		location = time.UTC
-	}
 }
 
 func main() {
-	strSpec := getEnvString("CRON_SCHEDULE", defaultCron)
-	schedule := validateCronSpec(strSpec)
-
-	filegen := &FileGenerator{
-		c: climacell.New(getEnvString("CLIMACELL_API_KEY", "")),
-		loc: &climacell.LatLon{
-			Lat: getEnvAsFloat64("LATITUDE", 35.780361),
-			Lon: getEnvAsFloat64("LONGITUDE", -78.639111),
-		},
-		sched: schedule,
-	}
-
-	if err := filegen.genFile(); err != nil {
-		logrus.Info("output is jacked, probably: %v", err)
+	configPath := getEnvString("CONFIG_FILE", "config.yaml")
+	_, required := os.LookupEnv("CONFIG_FILE")
+	server, err := NewServer(configPath, required, "out")
+	if err != nil {
+		logrus.Fatalf("failed to start: %v", err)
 	}
+	go server.Watch()
 
-	cron := cron.New()
-	cron.Schedule(filegen.sched, filegen)
-	logrus.Infof("starting cronjob on schedule: %s", strSpec)
-	cron.Start()
-
-	fs := http.FileServer(http.Dir("./out"))
-	http.Handle("/out/", http.StripPrefix("/out", fs))
+	http.HandleFunc("/out/", server.instrument("output", server.ServeOutput))
+	http.HandleFunc("/render", server.instrument("render", server.ServeRender))
+	http.HandleFunc("/api/v1/forecast", server.instrument("forecast", server.ServeForecast))
+	http.Handle("/metrics", promhttp.Handler())
 	logrus.Fatal(http.ListenAndServe(":53084", nil))
 	logrus.Info("exiting")
 }
 
+// FileGenerator generates the image for one device. A reload hands it new
+// settings with configure without waiting for a run in progress; each run
+// takes the latest settings when it starts, so it never mixes old and new
+// ones.
 type FileGenerator struct {
-	c     *climacell.Client
-	loc   *climacell.LatLon
-	sched cron.Schedule
+	// id is the device's id, which never changes for a generator.
+	id string
+	// next holds the *generatorSettings for the next run.
+	next atomic.Value
+
+	// mu is held for a whole run.
+	mu sync.Mutex
+	// settings are those of the current or last run.
+	settings *generatorSettings
+
+	// alerting is set while the last image showed an alert.
+	alerting bool
+	lastRun  time.Time
+	// forecast is the last forecast fetched for the device, shown again
+	// with a staleness banner while every provider fails.
+	forecast *Forecast
+
+	// etags identify the last output files written, by name.
+	etags map[string]string
+
+	// outMu guards what ServeOutput reads, so requests are not held up by a
+	// run. failure is served instead of the image while generation fails and
+	// the last image is missing or out of date. modified holds, by output
+	// file name, the time of the forecast data in the file, moved forward
+	// when the file changed without new data.
+	outMu    sync.Mutex
+	failure  *Failure
+	modified map[string]time.Time
+	// shown is what the last image was drawn from.
+	shown shownData
+}
+
+// shownData is the data an image was drawn from.
+type shownData struct {
+	device   *Device
+	forecast *Forecast
+	alerts   []Alert
+}
+
+// generatorSettings are what a FileGenerator draws with. They are not
+// modified once handed to a generator.
+type generatorSettings struct {
+	provider   WeatherProvider
+	alerts     AlertSources
+	rasterizer Rasterizer
+	layout     *Layout
+	device     *Device
+}
+
+// configure sets the settings for the generator's next run and reports
+// whether the device's own settings or its layout changed. A run in progress
+// finishes with the settings it started with.
+func (f *FileGenerator) configure(settings *generatorSettings) bool {
+	old, _ := f.next.Load().(*generatorSettings)
+	f.next.Store(settings)
+	return old == nil || old.device.OutDir != settings.device.OutDir ||
+		!reflect.DeepEqual(old.device.config, settings.device.config) || old.layout.source != settings.layout.source
 }
 
 func (f *FileGenerator) Run() {
-	if err := f.genFile(); err != nil {
-		logrus.Errorf("failed to generate file: %v", err)
+	f.run(true)
+}
+
+// run generates the image. Without fetch the last forecast is drawn again
+// with the current alerts, so alert runs do not spend provider quota; the
+// providers are only asked when there is no last forecast for the device.
+func (f *FileGenerator) run(fetch bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	settings := f.next.Load().(*generatorSettings)
+	if old := f.settings; old != nil && (old.device.Location.Lat != settings.device.Location.Lat ||
+		old.device.Location.Lon != settings.device.Location.Lon || old.device.Units.Base() != settings.device.Units.Base()) {
+		// the last forecast is for another place or unit system
+		f.forecast = nil
 	}
+	f.settings = settings
+	f.lastRun = time.Now()
+	fetched, stale, err := f.genFile(fetch)
+	switch {
+	case err != nil:
+		logrus.Errorf("failed to generate file for %s: %v", f.id, err)
+		generations.WithLabelValues(f.id, failureKind(err)).Inc()
+	case stale:
+		logrus.Warnf("generated file for %s is stale", f.id)
+		generations.WithLabelValues(f.id, "stale").Inc()
+	case !fetched:
+		generations.WithLabelValues(f.id, "alerts").Inc()
+	default:
+		generations.WithLabelValues(f.id, "ok").Inc()
+	}
+	failure := f.failureImage(err)
+	f.outMu.Lock()
+	f.failure = failure
+	f.outMu.Unlock()
 }
 
-func (f *FileGenerator) genFile() error {
-	t := template.Must(template.New("output").Parse(svgOutput))
+// failureImage returns the error image to serve after a run that returned
+// err, or nil when the run succeeded or the last image is still recent
+// enough to serve. f.mu must be held.
+func (f *FileGenerator) failureImage(err error) *Failure {
+	if err == nil {
+		return nil
+	}
+	now := time.Now()
+	var lastSuccess time.Time
+	device := f.settings.device
+	if info, statErr := os.Stat(filepath.Join(device.OutDir, "output.png")); statErr == nil {
+		lastSuccess = info.ModTime()
+		if now.Sub(lastSuccess) <= device.StaleAfter {
+			return nil
+		}
+	}
+	failure, renderErr := renderFailure(f.settings.rasterizer, device, failureKind(err), err, now, lastSuccess)
+	if renderErr != nil {
+		logrus.Errorf("failed to draw error image for %s: %v", f.id, renderErr)
+		return nil
+	}
+	return failure
+}
 
-	start := time.Now()
+// Failure returns the error image to serve instead of the device's image,
+// or nil.
+func (f *FileGenerator) Failure() *Failure {
+	f.outMu.Lock()
+	defer f.outMu.Unlock()
+	return f.failure
+}
 
-	logrus.Info("getting realtime data")
-	current, err := f.c.RealTime(climacell.ForecastArgs{
-		Location:   f.loc,
-		UnitSystem: "us",
-		Fields:     []string{realTimeFields},
-	})
-	if err != nil {
-		return fmt.Errorf("error getting realTime data: %v", err)
-	}
+// Shown returns the device settings, forecast and alerts the last image was
+// drawn from, all nil when no image was generated since the server started.
+func (f *FileGenerator) Shown() (*Device, *Forecast, []Alert) {
+	f.outMu.Lock()
+	defer f.outMu.Unlock()
+	return f.shown.device, f.shown.forecast, f.shown.alerts
+}
 
-	dayOrNight := getDayOrNight(start, *current.Sunrise.Value, *current.Sunset.Value)
+// Modified returns when the data in the device's output file name last
+// changed, or the zero time when it was not generated since the server
+// started.
+func (f *FileGenerator) Modified(name string) time.Time {
+	f.outMu.Lock()
+	defer f.outMu.Unlock()
+	return f.modified[name]
+}
 
-	logrus.Info("getting daily forecast data")
-	daily, err := f.c.DailyForecast(climacell.ForecastArgs{
-		Location:   f.loc,
-		UnitSystem: "us",
-		Fields:     []string{dailyFields},
-		Start:      start,
-		End:        time.Now().Add(24 * 5 * time.Hour),
-	})
-	if err != nil {
-		return fmt.Errorf("error getting forecast data: %v", err)
+// alertJob refreshes a FileGenerator's alerts on the device's alert
+// schedule, but only while an alert is in effect and the regular schedule
+// has not just run it. The forecast is not fetched again.
+type alertJob struct {
+	f *FileGenerator
+}
+
+func (j alertJob) Run() {
+	j.f.mu.Lock()
+	skip := !j.f.alerting || time.Since(j.f.lastRun) < 30*time.Second
+	j.f.mu.Unlock()
+	if !skip {
+		logrus.Infof("alert in effect for %s, regenerating", j.f.id)
+		j.f.run(false)
 	}
+}
 
-	updatedTime := start.In(location).Format("Monday Jan 2, 15:04 MST")
-	today := daily[0]
-	tomorrow := daily[1]
-	in2days := daily[2]
-	in3days := daily[3]
-
-	substitutions := &ImageSubs{
-		TempNow:    strconv.FormatFloat(*current.Temp.Value, 'f', 0, 64),
-		Sunrise:    current.Sunrise.Value.In(location).Format(time.Kitchen),
-		Sunset:     current.Sunset.Value.In(location).Format(time.Kitchen),
-		MoonPhase:  getMoonPhase(*current.MoonPhase.Value),
-		WindSpeed:  strconv.FormatFloat(*current.WindSpeed.Value, 'f', 0, 64),
-		WindDir:    strconv.FormatFloat(*current.WindDirection.Value, 'f', 0, 64),
-		HighOne:    strconv.FormatFloat(*today.Temp.Max().Value.Value, 'f', 0, 64),
-		HighTwo:    strconv.FormatFloat(*tomorrow.Temp.Max().Value.Value, 'f', 0, 64),
-		HighThree:  strconv.FormatFloat(*in2days.Temp.Max().Value.Value, 'f', 0, 64),
-		HighFour:   strconv.FormatFloat(*in3days.Temp.Max().Value.Value, 'f', 0, 64),
-		LowOne:     strconv.FormatFloat(*today.Temp.Min().Value.Value, 'f', 0, 64),
-		LowTwo:     strconv.FormatFloat(*tomorrow.Temp.Min().Value.Value, 'f', 0, 64),
-		LowThree:   strconv.FormatFloat(*in2days.Temp.Min().Value.Value, 'f', 0, 64),
-		LowFour:    strconv.FormatFloat(*in3days.Temp.Min().Value.Value, 'f', 0, 64),
-		DayTwo:     tomorrow.ObservationTime.Value.Weekday().String(),
-		DayThree:   in2days.ObservationTime.Value.Weekday().String(),
-		DayFour:    in3days.ObservationTime.Value.Weekday().String(),
-		IconOne:    getWeatherIcon(*current.WeatherCode.Value, dayOrNight),
-		IconTwo:    *tomorrow.WeatherCode.Value,
-		IconThree:  *in2days.WeatherCode.Value,
-		IconFour:   *in3days.WeatherCode.Value,
-		IconMoon:   *current.MoonPhase.Value,
-		Latitude:   strconv.FormatFloat(f.loc.Lat, 'f', 3, 64),
-		Longitude:  strconv.FormatFloat(f.loc.Lon, 'f', 3, 64),
-		DateString: updatedTime,
+// genFile must be called with f.mu held. Outputs are replaced atomically, so
+// a failed run leaves the last image in place. Without fetch the last
+// forecast is drawn again when there is one; fetched reports whether a new
+// forecast was drawn. When the providers fail the last forecast is drawn
+// again once it is older than the device's StaleAfter, so the image says
+// that it is out of date; stale reports that the image was drawn from it.
+func (f *FileGenerator) genFile(fetch bool) (fetched, stale bool, err error) {
+	start := time.Now()
+	settings := f.settings
+	device := settings.device
+	timer := newStageTimer(device.ID)
+
+	forecast := f.forecast
+	if fetch || forecast == nil {
+		forecast, err = settings.provider.Forecast(device.Location, device.Units.Base())
+		if err != nil {
+			err = fmt.Errorf("error getting forecast from %s: %w", settings.provider.Name(), err)
+			if f.forecast == nil || start.Sub(f.forecast.FetchedAt) <= device.StaleAfter {
+				return false, false, err
+			}
+			logrus.Errorf("%v; showing the forecast from %s", err, f.forecast.FetchedAt.Format(time.RFC3339))
+			forecast, stale = f.forecast, true
+		} else {
+			f.forecast, fetched = forecast, true
+		}
 	}
+	logrus.Infof("using forecast from %s", forecast.Source)
+
+	alerts := settings.alerts.Alerts(device.Location, start)
+	f.alerting = len(alerts) > 0
+	timer.done("fetch")
 
-	if _, err := os.Stat("out"); os.IsNotExist(err) {
-		logrus.Info("creating `out` folder")
-		if err := os.Mkdir("out", 0777); err != nil {
-			return fmt.Errorf("cannot create `out` folder: %v", err)
+	if _, err := os.Stat(device.OutDir); os.IsNotExist(err) {
+		logrus.Infof("creating `%s` folder", device.OutDir)
+		if err := os.MkdirAll(device.OutDir, 0777); err != nil {
+			return fetched, stale, fmt.Errorf("cannot create `%s` folder: %v", device.OutDir, err)
 		}
 	}
 
-	output, err := os.OpenFile("out/output.svg", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
+	var svg bytes.Buffer
+	if err := settings.layout.Execute(&svg, newTemplateData(forecast, alerts, device, start)); err != nil {
+		return fetched, stale, err
+	}
+	timer.done("template")
+
+	img, err := rasterize(settings.rasterizer, device, svg.Bytes())
 	if err != nil {
-		return fmt.Errorf("error opening file: %v", err)
+		return fetched, stale, err
 	}
-	if err := t.Execute(output, substitutions); err != nil {
-		return fmt.Errorf("template execution error: %v", err)
+	timer.done("rasterize")
+	png, err := device.Quantizer.EncodePNG(device.Profile.Fit(img))
+	if err != nil {
+		return fetched, stale, err
 	}
+	timer.done("compress")
 
-	logrus.Info("writing output to svg")
-	if err := output.Close(); err != nil {
-		return fmt.Errorf("error closing file: %v", err)
+	logrus.Info("writing output to svg and png")
+	if err := writeFileAtomic(filepath.Join(device.OutDir, "output.svg"), svg.Bytes()); err != nil {
+		return fetched, stale, fmt.Errorf("error writing svg: %v", err)
+	}
+	if err := writeFileAtomic(filepath.Join(device.OutDir, "output.png"), png); err != nil {
+		return fetched, stale, fmt.Errorf("error writing png: %v", err)
 	}
+	if fetched {
+		lastSuccess.WithLabelValues(device.ID).SetToCurrentTime()
+		imageBytes.WithLabelValues(device.ID, "svg").Set(float64(svg.Len()))
+		imageBytes.WithLabelValues(device.ID, "png").Set(float64(len(png)))
+	}
+
+	f.outMu.Lock()
+	defer f.outMu.Unlock()
+	f.shown = shownData{device: device, forecast: forecast, alerts: alerts}
+	f.changed("output.svg", svg.Bytes(), forecast.Updated(), start)
+	f.changed("output.png", png, forecast.Updated(), start)
+	return fetched, stale, nil
+}
 
-	logrus.Info("converting svg to png")
-	if err := exec.Command("rsvg-convert", "out/output.svg", "-b", "white", "-f", "png", "-o", "out/output.png").Run(); err != nil {
-		return fmt.Errorf("error convert svg to png: %v", err)
+// changed moves the modified time of the output file name forward when data
+// differs from what was last written to it: to updated, the time of the
+// forecast data, or to start when the file changed without new data. It
+// must be called with f.mu and f.outMu held.
+func (f *FileGenerator) changed(name string, data []byte, updated, start time.Time) {
+	if f.etags == nil {
+		f.etags, f.modified = make(map[string]string), make(map[string]time.Time)
+	}
+	etag := contentETag(data)
+	if etag == f.etags[name] {
+		return
+	}
+	f.etags[name] = etag
+	if !updated.After(f.modified[name]) {
+		updated = start
 	}
-	logrus.Info("created .png output")
+	f.modified[name] = updated
+}
 
-	logrus.Info("crushing .png")
-	if err := exec.Command("pngcrush", "-c", "0", "-ow", "out/output.png").Run(); err != nil {
-		return fmt.Errorf("error crushing png: %v", err)
+// encodeImage rasterizes svg and encodes it as a PNG for device's screen.
+func encodeImage(r Rasterizer, device *Device, svg []byte) ([]byte, error) {
+	img, err := rasterize(r, device, svg)
+	if err != nil {
+		return nil, err
 	}
-	logrus.Info("crushed .png")
-	return nil
+	return device.Quantizer.EncodePNG(device.Profile.Fit(img))
 }
 
-type ImageSubs struct {
-	TempNow    string
-	Sunrise    string
-	Sunset     string
-	MoonPhase  string
-	WindSpeed  string
-	WindDir    string
-	HighOne    string
-	HighTwo    string
-	HighThree  string
-	HighFour   string
-	LowOne     string
-	LowTwo     string
-	LowThree   string
-	LowFour    string
-	DayTwo     string
-	DayThree   string
-	DayFour    string
-	IconOne    string
-	IconTwo    string
-	IconThree  string
-	IconFour   string
-	IconMoon   string
-	Latitude   string
-	Longitude  string
-	DateString string
+// rasterize draws svg scaled to device's screen.
+func rasterize(r Rasterizer, device *Device, svg []byte) (image.Image, error) {
+	width, height, err := svgSize(svg)
+	if err != nil {
+		return nil, err
+	}
+	return r.Rasterize(svg, device.Profile.Scale(width, height))
 }
 
-const svgOutput = `
-<svg xmlns="http://www.w3.org/2000/svg" height="800" width="600" version="1.1" xmlns:xlink="http://www.w3.org/1999/xlink">
-
-<defs>
-<g id="clear">
-	<use xlink:href="#clear_day"/>
-</g>
-<g id="clear_day">
-	<rect fill="none" width="24" height="24"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M12.0005,6c-3.3141,0-6,2.687-6,6s2.6859,6,6,6c3.313,0,6-2.687,6-6S15.3135,6,12.0005,6z M12,17c-2.7568,0-5-2.2432-5-5s2.2432-5,5-5s5,2.2432,5,5S14.7568,17,12,17z"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M12.0004,5L12.0004,5c-0.2761,0-0.5-0.2239-0.5-0.5v-2c0-0.2761,0.2239-0.5,0.5-0.5h0c0.2761,0,0.5,0.2239,0.5,0.5v2C12.5004,4.7761,12.2765,5,12.0004,5z"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M12.0004,22L12.0004,22c-0.2761,0-0.5-0.2239-0.5-0.5v-2c0-0.2761,0.2239-0.5,0.5-0.5h0c0.2761,0,0.5,0.2239,0.5,0.5v2C12.5004,21.7761,12.2765,22,12.0004,22z"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M5.0004,12L5.0004,12c0,0.2761-0.2239,0.5-0.5,0.5h-2c-0.2761,0-0.5-0.2239-0.5-0.5v0c0-0.2761,0.2239-0.5,0.5-0.5h2C4.7765,11.5,5.0004,11.7239,5.0004,12z"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M22.0004,12L22.0004,12c0,0.2761-0.2239,0.5-0.5,0.5h-2c-0.2761,0-0.5-0.2239-0.5-0.5v0c0-0.2761,0.2239-0.5,0.5-0.5h2C21.7765,11.5,22.0004,11.7239,22.0004,12z"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M16.9501,7.0503L16.9501,7.0503c-0.1953-0.1953-0.1953-0.5118,0-0.7071l1.4142-1.4142c0.1953-0.1953,0.5118-0.1953,0.7071,0v0c0.1953,0.1953,0.1953,0.5118,0,0.7071l-1.4142,1.4142C17.462,7.2455,17.1454,7.2455,16.9501,7.0503z"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M4.9293,19.0711L4.9293,19.0711c-0.1953-0.1953-0.1953-0.5118,0-0.7071l1.4142-1.4142c0.1953-0.1953,0.5118-0.1953,0.7071,0h0c0.1953,0.1953,0.1953,0.5118,0,0.7071l-1.4142,1.4142C5.4412,19.2663,5.1246,19.2663,4.9293,19.0711z"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M7.0507,7.0503L7.0507,7.0503c-0.1953,0.1953-0.5118,0.1953-0.7071,0L4.9293,5.636c-0.1953-0.1953-0.1953-0.5118,0-0.7071l0,0c0.1953-0.1953,0.5118-0.1953,0.7071,0l1.4142,1.4142C7.2459,6.5384,7.2459,6.855,7.0507,7.0503z"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M19.0715,19.0711L19.0715,19.0711c-0.1953,0.1953-0.5118,0.1953-0.7071,0l-1.4142-1.4142c-0.1953-0.1953-0.1953-0.5118,0-0.7071l0,0c0.1953-0.1953,0.5118-0.1953,0.7071,0l1.4142,1.4142C19.2667,18.5592,19.2667,18.8758,19.0715,19.0711z"/>
-</g>
-<g id="clear_night">
-	<rect fill="none" width="24" height="24"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M19,16.0001C13.4772,16.0001,9,11.5228,9,6c0-1.3221,0.2566-2.5842,0.7225-3.7394C5.297,3.2913,2,7.2607,2,12c0,5.5228,4.4772,10.0001,10,10.0001c4.2008,0,7.7968-2.5904,9.2775-6.2607C20.546,15.9099,19.7836,16.0001,19,16.0001z"/>
-</g>
-<g id="cloudy">
-	<rect fill="none" width="24" height="24"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M20.8403,6.4685C20.274,4.0722,18.2131,2.2941,15.75,2.2941c-2.2434,0-4.1527,1.4763-4.9044,3.5506c0.3456,0.0278,0.6844,0.0732,1.0164,0.1465c0.6582-1.585,2.1508-2.6974,3.8883-2.6974c1.9502,0,3.6426,1.3999,4.1162,3.4048l0.1768,0.7441l0.7637,0.0254c1.2295,0.0405,2.1934,1.0986,2.1934,2.4082c0,1.333-1.0146,2.4175-2.2627,2.4175h-0.9351c0.4901,0.2424,0.927,0.5667,1.2916,0.9625C22.7262,13.0681,24,11.6356,24,9.8762C24,8.0251,22.5937,6.5268,20.8403,6.4685z"/>
-	<path d="M10.2442,6.2941c3.2065,0,5.9841,2.1664,6.7546,5.2684l0.1824,0.7346l0.7565,0.0239c2.146,0.0677,3.827,1.7899,3.827,3.9207c0,2.1697-1.7726,3.9348-3.9513,3.9348H3.6993C2.2109,20.1764,1,18.9711,1,17.4894c0-1.0995,0.6649-2.0764,1.694-2.4888l0.7008-0.2808l-0.0781-0.751c-0.027-0.2595-0.0402-0.4995-0.0402-0.7336C3.2765,9.4079,6.4023,6.2941,10.2442,6.2941 M10.2442,5.2941c-4.4005,0-7.9677,3.5543-7.9677,7.9412c0,0.2836,0.017,0.5627,0.0455,0.8372C0.9629,14.6171,0,15.9387,0,17.4894c0,2.0353,1.6561,3.687,3.6992,3.687h14.1141c2.7352,0,4.9513-2.2099,4.9513-4.9348c0-2.6729-2.1342-4.8361-4.7954-4.9202C17.1099,7.8614,13.9821,5.2941,10.2442,5.2941L10.2442,5.2941z"/>
-</g>
-<g id="drizzle">
-	<rect fill="none" width="24" height="24"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M11.5342,20.0402c-0.064,0-0.129-0.012-0.192-0.0381c-0.255-0.1059-0.376-0.399-0.27-0.6529l0.478-1.155c0.106-0.2561,0.401-0.375,0.654-0.2711c0.255,0.1061,0.376,0.399,0.27,0.654l-0.478,1.1541C11.9162,19.9241,11.7302,20.0402,11.5342,20.0402 M8.2602,19.7312l0.478-1.1541c0.106-0.255-0.015-0.5479-0.27-0.654c-0.253-0.1039-0.547,0.015-0.653,0.2711l-0.479,1.155c-0.105,0.2539,0.016,0.5471,0.271,0.6529c0.062,0.0261,0.127,0.0381,0.191,0.0381C7.9942,20.0402,8.1802,19.9241,8.2602,19.7312 M15.7322,19.7312l0.478-1.1541c0.106-0.255-0.016-0.5479-0.27-0.654c-0.253-0.1039-0.548,0.015-0.654,0.2711l-0.478,1.155c-0.106,0.2539,0.015,0.5471,0.27,0.6529c0.063,0.0261,0.128,0.0381,0.192,0.0381C15.4652,20.0402,15.6522,19.9241,15.7322,19.7312"/>
-	<path d="M10.9999,2.9999c2.7609,0,5.1526,1.8724,5.8163,4.5533l0.182,0.7352l0.757,0.024c1.8195,0.0577,3.2448,1.5238,3.2448,3.3376c0,1.8472-1.5028,3.35-3.35,3.35h-12.4c-1.2406,0-2.25-1.0094-2.25-2.2501c0-0.921,0.5547-1.7391,1.4131-2.0842l0.6992-0.2811L5.0347,9.6351C5.0112,9.4092,4.9999,9.2014,4.9999,9C4.9999,5.6916,7.6915,2.9999,10.9999,2.9999 M10.9999,1.9999c-3.866,0-7,3.1331-7,7.0001c0,0.2501,0.015,0.4961,0.04,0.738c-1.194,0.48-2.04,1.6451-2.04,3.012c0,1.794,1.4551,3.2501,3.25,3.2501h12.4c2.403,0,4.35-1.9481,4.35-4.35c0-2.3561-1.875-4.263-4.213-4.3372C17.0319,4.263,14.2839,1.9999,10.9999,1.9999L10.9999,1.9999z"/>
-</g>
-<g id="flurries">
-	<rect fill="none" width="24" height="24"/>
-	<path d="M18.4042,22c-0.8018,0-1.5547-0.312-2.1211-0.8784c-0.1953-0.1953-0.1953-0.5117,0-0.707s0.5117-0.1953,0.707,0C17.3681,20.7919,17.8701,21,18.4042,21c1.1025,0,2-0.897,2-2s-0.8975-2-2-2H3.2499c-0.2764,0-0.5-0.2236-0.5-0.5s0.2236-0.5,0.5-0.5h15.1543c1.6543,0,3,1.3457,3,3S20.0585,22,18.4042,22z M13.9042,21c0-1.6543-1.3457-3-3-3H3.2499c-0.2764,0-0.5,0.2236-0.5,0.5s0.2236,0.5,0.5,0.5h7.6543c1.1025,0,2,0.897,2,2s-0.8975,2-2,2s-2-0.897-2-2c0-0.2764-0.2236-0.5-0.5-0.5s-0.5,0.2236-0.5,0.5c0,1.6543,1.3457,3,3,3S13.9042,22.6542,13.9042,21z"/>
-	<path d="M10.9999,1.9999c2.7609,0,5.1526,1.8724,5.8163,4.5533l0.182,0.7352l0.757,0.024c1.8195,0.0577,3.2448,1.5237,3.2448,3.3376c0,1.8472-1.5028,3.35-3.35,3.35h-12.4c-1.2406,0-2.25-1.0094-2.25-2.2501c0-0.921,0.5547-1.7391,1.4131-2.0842l0.6992-0.2811L5.0347,8.6351C5.0112,8.4092,4.9999,8.2015,4.9999,8C4.9999,4.6916,7.6915,1.9999,10.9999,1.9999 M10.9999,0.9999c-3.866,0-7,3.1331-7,7.0001c0,0.2501,0.015,0.4961,0.04,0.738c-1.194,0.48-2.04,1.6451-2.04,3.012c0,1.794,1.4551,3.2501,3.25,3.2501h12.4c2.403,0,4.35-1.9481,4.35-4.35c0-2.3561-1.875-4.263-4.213-4.3372C17.0319,3.263,14.2839,0.9999,10.9999,0.9999L10.9999,0.9999z"/>
-</g>
-<g id="fog_light">
-	<rect fill-rule="evenodd" clip-rule="evenodd" fill="none" width="24" height="24"/>
-	<path d="M11.9969,17.3595c-2.5781,0-3.8857-0.4189-5.1514-0.8237c-1.2471-0.3994-2.4248-0.7764-4.8457-0.7764c-0.2764,0-0.5-0.2236-0.5-0.5s0.2236-0.5,0.5-0.5c2.5771,0,3.8848,0.4189,5.1504,0.8237c1.2471,0.3994,2.4248,0.7764,4.8467,0.7764s3.6006-0.377,4.8486-0.7764c1.2656-0.4048,2.5752-0.8237,5.1543-0.8237c0.2764,0,0.5,0.2236,0.5,0.5s-0.2236,0.5-0.5,0.5c-2.4229,0-3.6016,0.377-4.8496,0.7764C15.8846,16.9405,14.575,17.3595,11.9969,17.3595z"/>
-	<path d="M11.9969,20.3595c-0.8301,0-1.5664-0.0425-2.251-0.1299C9.4725,20.1944,9.2781,19.944,9.3133,19.67c0.0352-0.2744,0.2852-0.4644,0.5596-0.4326c0.6416,0.082,1.3369,0.1221,2.124,0.1221c0.6387,0,1.2109-0.0259,1.75-0.0791c0.2734-0.0205,0.5195,0.1738,0.5469,0.4482c0.0264,0.2749-0.1738,0.5195-0.4492,0.5469C13.2733,20.3321,12.6688,20.3595,11.9969,20.3595z M17.6678,19.3497c-0.2148,0-0.4141-0.1396-0.4785-0.356c-0.0801-0.2646,0.0703-0.5435,0.335-0.623c0.9824-0.2949,2.1553-0.5728,4.0703-0.6074c0.2861-0.0039,0.5039,0.2148,0.5088,0.4912c0.0049,0.2759-0.2148,0.5039-0.4912,0.5088c-1.7939,0.0322-2.8867,0.2905-3.8008,0.5654C17.7635,19.3429,17.7156,19.3497,17.6678,19.3497z M5.95,19.2403c-0.0439,0-0.0889-0.0059-0.1328-0.0181c-0.8301-0.229-1.9316-0.4629-3.8174-0.4629c-0.2764,0-0.5-0.2236-0.5-0.5s0.2236-0.5,0.5-0.5c2.0049,0,3.1895,0.2524,4.083,0.499c0.2666,0.0732,0.4229,0.3486,0.3496,0.6147C6.3709,19.0948,6.1697,19.2403,5.95,19.2403z"/>
-	<path d="M4.4131,10.6658l0.6992-0.2811L5.0348,9.6351C5.0114,9.4091,5,9.2014,5,9c0-3.3084,2.6917-6,6-6c2.7609,0,5.1526,1.8724,5.8163,4.5533l0.182,0.7352l0.7571,0.024C19.5748,8.3701,21,9.8361,21,11.65c0,0.3873-0.0791,0.7536-0.2007,1.1h1.043C21.9351,12.3966,22,12.0325,22,11.65c0-2.356-1.875-4.263-4.213-4.337C17.032,4.263,14.2841,2,11,2C7.134,2,4,5.133,4,9c0,0.25,0.015,0.496,0.04,0.738C2.8459,10.218,2,11.383,2,12.75h1C3,11.829,3.5547,11.0109,4.4131,10.6658z"/>
-</g>
-<g id="fog">
-	<rect fill="none" width="24" height="24"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M11.9968,21.8592c-2.577,0-3.886-0.4191-5.151-0.823c-1.247-0.4001-2.425-0.777-4.846-0.777c-0.276,0-0.5-0.223-0.5-0.4999c0-0.276,0.224-0.5001,0.5-0.5001c2.578,0,3.886,0.4191,5.151,0.8241c1.247,0.399,2.425,0.7759,4.846,0.7759c2.423,0,3.601-0.3769,4.849-0.7759c1.266-0.405,2.575-0.8241,5.154-0.8241c0.277,0,0.5,0.2241,0.5,0.5001c0,0.2769-0.223,0.4999-0.5,0.4999c-2.422,0-3.601,0.3769-4.849,0.777C15.8848,21.4401,14.5758,21.8592,11.9968,21.8592 M11.9968,15.8592c-2.577,0-3.886-0.4191-5.151-0.823c-1.247-0.4001-2.425-0.777-4.846-0.777c-0.276,0-0.5-0.223-0.5-0.4999c0-0.276,0.224-0.5001,0.5-0.5001c2.578,0,3.886,0.4191,5.151,0.8241c1.247,0.399,2.425,0.7759,4.846,0.7759c2.423,0,3.601-0.3769,4.849-0.7759c1.266-0.405,2.575-0.8241,5.154-0.8241c0.277,0,0.5,0.2241,0.5,0.5001c0,0.2769-0.223,0.4999-0.5,0.4999c-2.422,0-3.601,0.3769-4.849,0.777C15.8848,15.4401,14.5758,15.8592,11.9968,15.8592 M11.9968,18.8592c-2.577,0-3.886-0.4191-5.151-0.823c-1.247-0.4001-2.425-0.777-4.846-0.777c-0.276,0-0.5-0.223-0.5-0.4999c0-0.276,0.224-0.5001,0.5-0.5001c2.578,0,3.886,0.4191,5.151,0.8241c1.247,0.399,2.425,0.7759,4.846,0.7759c2.423,0,3.601-0.3769,4.849-0.7759c1.266-0.405,2.575-0.8241,5.154-0.8241c0.277,0,0.5,0.2241,0.5,0.5001c0,0.2769-0.223,0.4999-0.5,0.4999c-2.422,0-3.601,0.3769-4.849,0.777C15.8848,18.4401,14.5758,18.8592,11.9968,18.8592"/>
-	<path d="M2.3633,11.267c0.4232,0.0064,0.8167,0.0233,1.1829,0.0494c0.2323-0.2743,0.5154-0.5091,0.8667-0.6503l0.6992-0.2811L5.0347,9.6353c-0.0234-0.226-0.0349-0.4337-0.0349-0.6351c0-3.3084,2.6917-6,6-6c2.761,0,5.1526,1.8724,5.8164,4.5533l0.1819,0.7352l0.7571,0.024c1.693,0.0536,3.0288,1.3314,3.2086,2.9693c0.3131-0.014,0.6459-0.0213,0.9974-0.0219c-0.1964-2.1701-1.969-3.8771-4.1743-3.9469c-0.7551-3.05-3.5029-5.313-6.7871-5.313c-3.866,0-7,3.133-7,7c0,0.25,0.0151,0.496,0.04,0.738C3.314,10.0301,2.72,10.5773,2.3633,11.267z"/>
-</g>
-<g id="freezing_drizzle">
-	<rect fill="none" width="24" height="24"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M11.753,9.5257c-0.064,0-0.129-0.012-0.192-0.0381c-0.255-0.1059-0.376-0.399-0.27-0.6529l0.478-1.155c0.106-0.256,0.401-0.375,0.654-0.2711c0.255,0.1061,0.376,0.399,0.27,0.654l-0.478,1.1541C12.135,9.4096,11.949,9.5257,11.753,9.5257 M8.479,9.2167l0.478-1.1541c0.106-0.255-0.015-0.5479-0.27-0.654C8.434,7.3047,8.14,7.4236,8.034,7.6797l-0.479,1.155C7.45,9.0886,7.571,9.3817,7.826,9.4876c0.062,0.0261,0.127,0.0381,0.191,0.0381C8.213,9.5257,8.399,9.4096,8.479,9.2167 M15.951,9.2167l0.478-1.1541c0.106-0.255-0.016-0.5479-0.27-0.654c-0.253-0.1039-0.548,0.015-0.654,0.2711l-0.478,1.155c-0.106,0.2539,0.015,0.5471,0.27,0.6529c0.063,0.0261,0.128,0.0381,0.192,0.0381C15.684,9.5257,15.871,9.4096,15.951,9.2167"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M21.0001,11.9998h-18c-0.552,0-1,1.0001-1,1.0001s0.448,1.0001,1,1.0001h1.0004c0.0022,0.001,0.9996,0.4485,0.9996,0.9999v2c0,0.553,1,1,1,1s1-0.447,1-1v-2c0-0.5514,0.9974-0.9989,0.9996-0.9999h0.0007c0.0022,0.001,0.9996,0.4485,0.9996,0.9999v2c0,0.553,1,1,1,1s1-0.447,1-1v-2c0-0.5514,0.9974-0.9989,0.9996-0.9999h0.0007c0.0022,0.001,0.9996,0.4485,0.9996,0.9999v2c0,0.553,1,1,1,1s1-0.447,1-1v-2c0-0.5514,0.9974-0.9989,0.9996-0.9999h0.0007c0.0022,0.001,0.9996,0.4485,0.9996,0.9999v2c0,0.553,1,1,1,1s1-0.447,1-1v-2c0-0.5514,0.9974-0.9989,0.9996-0.9999h1.0004c0.552,0,1-1.0001,1-1.0001S21.5521,11.9998,21.0001,11.9998z"/>
-</g>
-<g id="freezing_heavy_rain">
-	<rect fill="none" width="24" height="24"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M21.0001,11.9998h-18c-0.552,0-1,1.0001-1,1.0001s0.217,0.7189,0.535,0.8859c0.302,0.159,0.465,0.5,0.465,0.842v4.272c0,0.553,1,1,1,1s1-0.447,1-1v-4c0-0.552,1-1,1-1s1,0.448,1,1v4c0,0.553,1,1,1,1s1-0.447,1-1v-4c0-0.552,1-1,1-1s1,0.448,1,1v6c0,0.553,1,1,1,1s1-0.447,1-1v-6c0-0.552,1-1,1-1s1,0.448,1,1v4c0,0.553,1,1,1,1s1-0.447,1-1v-4c0-0.552,1-1,1-1s1,0.448,1,1v4c0,0.553,1,1,1,1s1-0.447,1-1v-4.272c0-0.342,0.162-0.683,0.465-0.842c0.318-0.1669,0.535-0.8859,0.535-0.8859S21.5521,11.9998,21.0001,11.9998"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M19.0093,9.8126L19.0093,9.8126c-0.2552-0.1059-0.3764-0.3983-0.2705-0.6535l2.0099-4.8499c0.1057-0.2552,0.3981-0.3764,0.6535-0.2707c0.2552,0.1059,0.3762,0.3985,0.2705,0.6536l-2.0099,4.8499C19.557,9.7973,19.2643,9.9183,19.0093,9.8126 M4.7197,9.543l2.0099-4.8497c0.1057-0.2552-0.0154-0.5478-0.2705-0.6535C6.2039,3.9341,5.9113,4.0551,5.8056,4.3103L3.7957,9.1602C3.6899,9.4154,3.8111,9.708,4.0663,9.8137C4.3214,9.9194,4.614,9.7984,4.7197,9.543 M8.4554,9.5428l2.0099-4.8499c0.1057-0.2552-0.0154-0.5478-0.2705-0.6535C9.9396,3.9337,9.6471,4.0548,9.5413,4.3099L7.5314,9.16C7.4257,9.4152,7.5468,9.7076,7.802,9.8135C8.0571,9.9191,8.3497,9.7982,8.4554,9.5428 M12.1911,9.5425l2.0099-4.8499c0.1059-0.255-0.0154-0.5476-0.2705-0.6533c-0.2552-0.1059-0.5478,0.0154-0.6535,0.2705l-2.0099,4.8499c-0.1057,0.2552,0.0154,0.5478,0.2705,0.6535C11.7929,9.9189,12.0854,9.7978,12.1911,9.5425 M15.9269,9.5425l2.0101-4.8501c0.1057-0.2552-0.0155-0.5478-0.2705-0.6535c-0.2554-0.1057-0.5479,0.0153-0.6535,0.2705l-2.0101,4.8501c-0.1057,0.255,0.0155,0.5476,0.2705,0.6535C15.5286,9.9185,15.8213,9.7975,15.9269,9.5425 M17.7948,9.5423l0.7261-1.7527c0.1059-0.2552-0.0152-0.5476-0.2705-0.6535c-0.2552-0.1057-0.5476,0.0155-0.6535,0.2705l-0.7261,1.7527c-0.1059,0.2552,0.0154,0.5476,0.2705,0.6535C17.3965,9.9183,17.6891,9.7973,17.7948,9.5423 M14.0591,9.5425L14.7853,7.79c0.1055-0.2552-0.0155-0.5478-0.2707-0.6535c-0.2552-0.1059-0.5478,0.0153-0.6535,0.2705l-0.7261,1.7527c-0.1059,0.255,0.0154,0.5476,0.2705,0.6533C13.6606,9.9187,13.9534,9.7976,14.0591,9.5425 M10.3232,9.5427l0.7263-1.7525c0.1057-0.255-0.0154-0.5476-0.2705-0.6535c-0.2552-0.1057-0.5478,0.0155-0.6535,0.2707L9.3992,9.1599C9.2935,9.4151,9.4146,9.7076,9.6699,9.8133C9.9249,9.9191,10.2175,9.798,10.3232,9.5427 M6.5875,9.5428l0.7263-1.7524C7.4195,7.5353,7.2982,7.2427,7.0431,7.137c-0.255-0.1059-0.5478,0.0154-0.6535,0.2705L5.6635,9.1602C5.5578,9.4152,5.6789,9.7078,5.934,9.8137C6.1894,9.9192,6.4818,9.7982,6.5875,9.5428 M19.1911,6.1729l0.6138-1.4808c0.1055-0.2552-0.0155-0.5478-0.2707-0.6535c-0.2552-0.1057-0.5478,0.0155-0.6533,0.2707l-0.6138,1.4808c-0.1057,0.255,0.0155,0.5478,0.2705,0.6533C18.7928,6.5494,19.0856,6.4281,19.1911,6.1729 M15.4554,6.1733l0.6136-1.4808c0.1059-0.2552-0.0154-0.5478-0.2705-0.6535c-0.2554-0.1057-0.5478,0.0153-0.6535,0.2705l-0.6136,1.4808c-0.1059,0.2552,0.0154,0.5479,0.2705,0.6535C15.0573,6.5497,15.3497,6.4285,15.4554,6.1733 M11.7197,6.1735l0.6136-1.4808c0.1057-0.2552-0.0154-0.5478-0.2705-0.6535c-0.2552-0.1057-0.5478,0.0155-0.6535,0.2705l-0.6136,1.481c-0.1059,0.255,0.0154,0.5478,0.2705,0.6535S11.614,6.4287,11.7197,6.1735 M7.984,6.1738l0.6136-1.4808c0.1057-0.2552-0.0155-0.5478-0.2707-0.6535c-0.255-0.1057-0.5478,0.0154-0.6535,0.2705L7.06,5.7909C6.9543,6.0461,7.0753,6.3388,7.3305,6.4444C7.5857,6.5502,7.8783,6.429,7.984,6.1738"/>
-</g>
-<g id="freezing_rain_light">
-	<rect fill="none" width="24" height="24"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M11.753,9.5257c-0.064,0-0.129-0.012-0.192-0.0381c-0.255-0.1059-0.376-0.399-0.27-0.6529l0.478-1.155c0.106-0.256,0.401-0.375,0.654-0.2711c0.255,0.1061,0.376,0.399,0.27,0.654l-0.478,1.1541C12.135,9.4096,11.949,9.5257,11.753,9.5257 M4.743,9.2167l0.479-1.155c0.105-0.255-0.016-0.5481-0.271-0.6531c-0.253-0.1059-0.547,0.015-0.653,0.27L3.819,8.8347C3.714,9.0897,3.835,9.3817,4.09,9.4876c0.062,0.0261,0.127,0.0381,0.191,0.0381C4.477,9.5257,4.663,9.4096,4.743,9.2167 M19.686,9.2167l0.479-1.1541c0.105-0.255-0.016-0.5479-0.271-0.654c-0.253-0.1039-0.547,0.015-0.653,0.2711l-0.478,1.155c-0.106,0.2539,0.015,0.5471,0.27,0.6529c0.063,0.0261,0.128,0.0381,0.191,0.0381C19.42,9.5257,19.607,9.4096,19.686,9.2167 M8.479,9.2167l0.478-1.1541c0.106-0.255-0.015-0.5479-0.27-0.654C8.434,7.3047,8.14,7.4236,8.034,7.6797l-0.479,1.155C7.45,9.0886,7.571,9.3817,7.826,9.4876c0.062,0.0261,0.127,0.0381,0.191,0.0381C8.213,9.5257,8.399,9.4096,8.479,9.2167 M15.951,9.2167l0.478-1.1541c0.106-0.255-0.016-0.5479-0.27-0.654c-0.253-0.1039-0.548,0.015-0.654,0.2711l-0.478,1.155c-0.106,0.2539,0.015,0.5471,0.27,0.6529c0.063,0.0261,0.128,0.0381,0.192,0.0381C15.684,9.5257,15.871,9.4096,15.951,9.2167"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M21.0001,11.9998h-18c-0.552,0-1,1.0001-1,1.0001s0.217,0.7189,0.535,0.8859c0.302,0.159,0.465,0.5,0.465,0.842v4.272c0,0.553,1,1,1,1s1-0.447,1-1v-4c0-0.552,1-1,1-1s1,0.448,1,1v2c0,0.553,1,1,1,1s1-0.447,1-1v-2c0-0.552,1-1,1-1s1,0.448,1,1v2c0,0.553,1,1,1,1s1-0.447,1-1v-2c0-0.552,1-1,1-1s1,0.448,1,1v2c0,0.553,1,1,1,1s1-0.447,1-1v-2c0-0.552,1-1,1-1s1,0.448,1,1v4c0,0.553,1,1,1,1s1-0.447,1-1v-4.272c0-0.342,0.162-0.683,0.465-0.842c0.318-0.1669,0.535-0.8859,0.535-0.8859S21.5521,11.9998,21.0001,11.9998"/>
-</g>
-<g id="freezing_rain">
-	<rect fill="none" width="24" height="24"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M11.7611,6.1546c-0.064,0-0.129-0.0109-0.192-0.0379c-0.255-0.105-0.376-0.3981-0.27-0.6531l0.478-1.1539c0.106-0.2561,0.399-0.375,0.654-0.2711c0.255,0.1061,0.376,0.399,0.27,0.654l-0.478,1.1541C12.1431,6.0386,11.9571,6.1546,11.7611,6.1546 M8.4871,5.8466l0.478-1.1541c0.106-0.255-0.015-0.5479-0.27-0.654c-0.255-0.1039-0.548,0.015-0.653,0.2711l-0.479,1.1539c-0.105,0.255,0.016,0.5481,0.271,0.6531c0.062,0.027,0.127,0.0379,0.191,0.0379C8.2211,6.1546,8.4071,6.0386,8.4871,5.8466 M9.6331,9.2176l0.478-1.155c0.106-0.255-0.015-0.5479-0.27-0.654c-0.253-0.104-0.547,0.016-0.653,0.271l-0.478,1.155c-0.106,0.255,0.015,0.5479,0.27,0.654c0.063,0.0249,0.127,0.0379,0.191,0.0379C9.3681,9.5266,9.5541,9.4096,9.6331,9.2176 M5.8891,9.2176l0.479-1.155c0.105-0.255-0.016-0.5479-0.271-0.654c-0.254-0.105-0.548,0.016-0.653,0.271l-0.479,1.155c-0.105,0.255,0.016,0.5479,0.271,0.654c0.062,0.0249,0.127,0.0379,0.191,0.0379C5.6231,9.5266,5.8101,9.4096,5.8891,9.2176 M4.7511,5.8466l0.479-1.155c0.105-0.255-0.016-0.5481-0.271-0.654c-0.254-0.105-0.548,0.0159-0.653,0.2709l-0.479,1.155c-0.105,0.2561,0.016,0.5481,0.271,0.6531c0.062,0.027,0.127,0.0379,0.191,0.0379C4.4851,6.1546,4.6711,6.0386,4.7511,5.8466M19.6941,5.8466l0.479-1.1541c0.105-0.255-0.016-0.5479-0.271-0.654c-0.254-0.1039-0.547,0.015-0.653,0.2711l-0.478,1.1539c-0.106,0.255,0.015,0.5481,0.27,0.6531c0.063,0.027,0.128,0.0379,0.191,0.0379C19.4281,6.1546,19.6151,6.0386,19.6941,5.8466M15.9591,5.8466l0.478-1.1541c0.106-0.255-0.016-0.5479-0.27-0.654c-0.255-0.1039-0.548,0.015-0.654,0.2711l-0.478,1.1539c-0.106,0.255,0.015,0.5481,0.27,0.6531c0.063,0.027,0.128,0.0379,0.192,0.0379C15.6921,6.1546,15.8791,6.0386,15.9591,5.8466M17.1211,9.2176l0.478-1.155c0.106-0.255-0.015-0.5479-0.27-0.654c-0.255-0.105-0.548,0.016-0.654,0.271l-0.478,1.155c-0.106,0.255,0.015,0.5479,0.27,0.654c0.063,0.0249,0.128,0.0379,0.192,0.0379C16.8551,9.5266,17.0411,9.4096,17.1211,9.2176M13.3771,9.2176l0.479-1.155c0.105-0.255-0.016-0.5479-0.271-0.654c-0.254-0.105-0.548,0.016-0.653,0.271l-0.479,1.155c-0.105,0.255,0.016,0.5479,0.271,0.654c0.062,0.0249,0.127,0.0379,0.191,0.0379C13.1111,9.5266,13.2971,9.4096,13.3771,9.2176"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M21.0001,11.9998h-18c-0.552,0-1,1.0001-1,1.0001s0.217,0.7189,0.535,0.8859c0.302,0.159,0.465,0.5,0.465,0.842v4.272c0,0.553,1,1,1,1s1-0.447,1-1v-4c0-0.552,1-1,1-1s1,0.448,1,1v2c0,0.553,1,1,1,1s1-0.447,1-1v-2c0-0.552,1-1,1-1s1,0.448,1,1v6c0,0.553,1,1,1,1s1-0.447,1-1v-6c0-0.552,1-1,1-1s1,0.448,1,1v2c0,0.553,1,1,1,1s1-0.447,1-1v-2c0-0.552,1-1,1-1s1,0.448,1,1v4c0,0.553,1,1,1,1s1-0.447,1-1v-4.272c0-0.342,0.162-0.683,0.465-0.842c0.318-0.1669,0.535-0.8859,0.535-0.8859S21.5521,11.9998,21.0001,11.9998"/>
-</g>
-<g id="ice_pellets_heavy">
-	<rect fill="none" width="24" height="24"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M13.0059,20.9617l-1.531-3.696l-3.695,1.531l1.531,3.696L13.0059,20.9617zM9.5079,13.5866l-1.848,0.765l0.766,1.848l1.847-0.7659L9.5079,13.5866z M19.4879,20.8648l-1.531-3.696l-3.695,1.531l1.531,3.696L19.4879,20.8648z M6.1007,20.9617l-1.531-3.696l-3.695,1.531l1.531,3.696L6.1007,20.9617z M15.9899,13.4897l-1.848,0.765l0.765,1.848l1.848-0.765L15.9899,13.4897z"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M14.2919,2.0388c0.255,0.105,0.376,0.398,0.27,0.6529l-3.827,9.2381c-0.079,0.1929-0.266,0.309-0.462,0.309c-0.064,0-0.129-0.012-0.191-0.0381c-0.255-0.1059-0.376-0.3979-0.271-0.6529l3.827-9.2389C13.7439,2.0527,14.0379,1.9308,14.2919,2.0388z M15.6059,5.9876c0.105-0.255,0.399-0.3769,0.653-0.27c0.255,0.105,0.376,0.3981,0.271,0.6531l-3.827,9.2389c-0.08,0.1931-0.266,0.309-0.462,0.309c-0.064,0-0.129-0.012-0.191-0.038c-0.255-0.1061-0.376-0.399-0.271-0.654L15.6059,5.9876z M22.0879,5.8907c0.105-0.255,0.399-0.3769,0.653-0.27c0.255,0.105,0.376,0.3981,0.271,0.6531l-3.827,9.2389c-0.08,0.1931-0.266,0.309-0.462,0.309c-0.064,0-0.129-0.012-0.191-0.038c-0.255-0.1061-0.376-0.399-0.271-0.654L22.0879,5.8907z M8.7007,5.9876c0.105-0.255,0.399-0.3769,0.653-0.27c0.255,0.105,0.376,0.3981,0.271,0.6531l-3.827,9.2389c-0.08,0.1931-0.266,0.309-0.462,0.309c-0.064,0-0.129-0.012-0.191-0.038c-0.255-0.1061-0.376-0.399-0.271-0.654L8.7007,5.9876z M20.7739,1.9419c0.255,0.105,0.376,0.398,0.27,0.6529l-3.827,9.2381c-0.079,0.1929-0.266,0.309-0.462,0.309c-0.064,0-0.129-0.012-0.191-0.0381c-0.255-0.1059-0.376-0.3979-0.271-0.6529l3.827-9.2389C20.2259,1.9558,20.5199,1.8339,20.7739,1.9419z"/>
-</g>
-<g id="ice_pellets_light">
-	<rect fill="none" width="24" height="24"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M12.2645,12.3374l-1.848,0.765l0.766,1.848l1.847-0.7659L12.2645,12.3374zM7.0545,14.0714l-1.848,0.7661l0.766,1.847l1.847-0.764L7.0545,14.0714z M15.2325,16.0175l-1.848,0.765l0.765,1.848l1.848-0.765L15.2325,16.0175z"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M15.1349,5.4088c0.255,0.105,0.376,0.398,0.27,0.6529l-1.9134,4.6188c-0.079,0.1929-0.266,0.309-0.462,0.309c-0.064,0-0.129-0.012-0.191-0.0381c-0.255-0.1059-0.376-0.3979-0.271-0.6529l1.9134-4.6196C14.5869,5.4228,14.8809,5.3008,15.1349,5.4088z M8.8886,8.337c0.106-0.255,0.4-0.3772,0.653-0.27c0.255,0.105,0.376,0.3979,0.271,0.6529l-1.5311,3.6955c-0.079,0.192-0.266,0.3081-0.462,0.3081c-0.064,0-0.129-0.0111-0.191-0.0381c-0.255-0.105-0.376-0.3979-0.271-0.6529L8.8886,8.337z M17.8316,8.4339c0.105-0.255,0.399-0.3769,0.653-0.27c0.255,0.105,0.376,0.3981,0.271,0.6531l-2.2961,5.5435c-0.08,0.1931-0.266,0.309-0.462,0.309c-0.064,0-0.129-0.012-0.191-0.038c-0.255-0.1061-0.376-0.399-0.271-0.654L17.8316,8.4339z"/>
-</g>
-<g id="ice_pellets">
-	<rect fill="none" width="24" height="24"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M13.5059,20.9617l-1.531-3.696l-3.695,1.531l1.531,3.696L13.5059,20.9617zM9.5079,13.5866l-1.848,0.765l0.766,1.848l1.847-0.7659L9.5079,13.5866z M4.7979,15.3206l-1.848,0.7661l0.766,1.847l1.847-0.764L4.7979,15.3206z M17.4899,13.4897l-1.848,0.765l0.765,1.848l1.848-0.765L17.4899,13.4897z"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M20.4719,4.9827c0.106-0.255,0.4-0.3769,0.654-0.27c0.255,0.105,0.376,0.3981,0.27,0.6531l-2.679,6.468c-0.08,0.192-0.266,0.3079-0.462,0.3079c-0.064,0-0.129-0.0109-0.191-0.038c-0.255-0.105-0.376-0.3981-0.271-0.6531L20.4719,4.9827z M14.2919,2.0388c0.255,0.105,0.376,0.398,0.27,0.6529l-3.827,9.2381c-0.079,0.1929-0.266,0.309-0.462,0.309c-0.064,0-0.129-0.012-0.191-0.0381c-0.255-0.1059-0.376-0.3979-0.271-0.6529l3.827-9.2389C13.7439,2.0527,14.0379,1.9308,14.2919,2.0388z M8.1629,5.8908c0.106-0.255,0.4-0.3772,0.653-0.27c0.255,0.105,0.376,0.3979,0.271,0.6529l-3.062,7.391c-0.079,0.192-0.266,0.3081-0.462,0.3081c-0.064,0-0.129-0.0111-0.191-0.0381c-0.255-0.105-0.376-0.3979-0.271-0.6529L8.1629,5.8908z M16.1059,5.9876c0.105-0.255,0.399-0.3769,0.653-0.27c0.255,0.105,0.376,0.3981,0.271,0.6531l-3.827,9.2389c-0.08,0.1931-0.266,0.309-0.462,0.309c-0.064,0-0.129-0.012-0.191-0.038c-0.255-0.1061-0.376-0.399-0.271-0.654L16.1059,5.9876z"/>
-</g>
-<g id="mostly_clear">
-	<use xlink:href="#mostly_clear_day"/>
-</g>
-<g id="mostly_clear_day">
-	<rect x="0.0002" fill="none" width="24" height="24"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M11.3215,3.5328L11.3215,3.5328c-0.2551,0.1057-0.5476-0.0155-0.6533-0.2706L9.9028,1.4145c-0.1057-0.2551,0.0155-0.5476,0.2706-0.6533l0,0c0.2551-0.1057,0.5476,0.0155,0.6533,0.2706l0.7654,1.8478C11.6977,3.1347,11.5766,3.4272,11.3215,3.5328z"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M23.239,6.1732L23.239,6.1732c0.1057,0.2551-0.0155,0.5476-0.2706,0.6533l-1.8478,0.7654c-0.2551,0.1057-0.5476-0.0155-0.6533-0.2706v0c-0.1057-0.2551,0.0155-0.5476,0.2706-0.6533l1.8478-0.7654C22.8409,5.7969,23.1334,5.918,23.239,6.1732z"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M16.679,3.5328L16.679,3.5328c-0.2551-0.1057-0.3763-0.3982-0.2706-0.6533l0.7654-1.8478c0.1057-0.2551,0.3982-0.3763,0.6533-0.2706l0,0c0.2551,0.1057,0.3763,0.3982,0.2706,0.6533l-0.7654,1.8478C17.2266,3.5174,16.9342,3.6385,16.679,3.5328z"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M7.5331,7.3212L7.5331,7.3212C7.4274,7.5763,7.1349,7.6975,6.8798,7.5918L5.0321,6.8264C4.7769,6.7208,4.6558,6.4283,4.7615,6.1732l0,0C4.8671,5.918,5.1596,5.7969,5.4147,5.9026l1.8478,0.7654C7.5176,6.7736,7.6388,7.0661,7.5331,7.3212z"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M23.239,13.8268L23.239,13.8268c-0.1057,0.2551-0.3982,0.3763-0.6533,0.2706l-1.8478-0.7654c-0.2551-0.1057-0.3763-0.3982-0.2706-0.6533v0c0.1057-0.2551,0.3982-0.3763,0.6533-0.2706l1.8478,0.7654C23.2236,13.2792,23.3447,13.5717,23.239,13.8268z"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M11.3214,3.5329L11.3214,3.5329c-0.2551,0.1057-0.5476-0.0155-0.6533-0.2706L9.9027,1.4145C9.797,1.1594,9.9182,0.8669,10.1733,0.7613l0,0c0.2551-0.1057,0.5476,0.0155,0.6533,0.2706l0.7654,1.8478C11.6976,3.1347,11.5765,3.4272,11.3214,3.5329z"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M23.2389,6.1732L23.2389,6.1732c0.1057,0.2551-0.0155,0.5476-0.2706,0.6533l-1.8478,0.7654c-0.2551,0.1057-0.5476-0.0155-0.6533-0.2706v0c-0.1057-0.2551,0.0155-0.5476,0.2706-0.6533l1.8478-0.7654C22.8408,5.7969,23.1333,5.9181,23.2389,6.1732z"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M16.6789,3.5329L16.6789,3.5329c-0.2551-0.1057-0.3763-0.3982-0.2706-0.6533l0.7654-1.8478c0.1057-0.2551,0.3982-0.3763,0.6533-0.2706l0,0c0.2551,0.1057,0.3763,0.3982,0.2706,0.6533l-0.7654,1.8478C17.2265,3.5174,16.934,3.6386,16.6789,3.5329z"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M7.533,7.3213L7.533,7.3213C7.4273,7.5764,7.1348,7.6975,6.8797,7.5919L5.0319,6.8265C4.7768,6.7208,4.6557,6.4283,4.7613,6.1732l0,0C4.867,5.9181,5.1595,5.7969,5.4146,5.9026L7.2624,6.668C7.5175,6.7737,7.6387,7.0661,7.533,7.3213z"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M9.0005,9.5004c0.0168,0,0.0327,0.004,0.0494,0.0042C9.303,6.9828,11.4127,5,13.9999,5c2.7568,0,5,2.2432,5,5c0,2.2724-1.5334,4.1741-3.613,4.7798l0.0052,0.0213l0.3789,0.0117c0.4835,0.0154,0.9387,0.1255,1.3593,0.299c1.7192-1.0551,2.87-2.9467,2.87-5.1118c0-3.3137-2.6863-6-6-6c-3.1727,0-5.7635,2.4643-5.9789,5.5823C8.3416,9.5336,8.6668,9.5004,9.0005,9.5004z"/>
-	<path d="M9,10.0003c2.7609,0,5.1526,1.8724,5.8163,4.5533l0.182,0.7352l0.7571,0.024C17.5748,15.3704,19,16.8364,19,18.6503c0,1.8472-1.5028,3.35-3.35,3.35H3.25c-1.2406,0-2.25-1.0093-2.25-2.25c0-0.921,0.5547-1.7391,1.4131-2.0842l0.6992-0.2811l-0.0775-0.7496C3.0114,16.4094,3,16.2017,3,16.0003C3,12.6919,5.6917,10.0003,9,10.0003 M9,9.0003c-3.866,0-7,3.133-7,7c0,0.25,0.015,0.496,0.04,0.738c-1.194,0.48-2.04,1.645-2.04,3.012c0,1.794,1.4551,3.25,3.25,3.25h12.4c2.403,0,4.35-1.948,4.35-4.35c0-2.356-1.875-4.263-4.213-4.337C15.032,11.2633,12.2841,9.0003,9,9.0003L9,9.0003z"/>
-</g>
-<g id="mostly_clear_night">
-	<rect fill="none" width="24" height="24"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M17.3018,12.4331l0.0908,0.3677l0.3789,0.0117c0.2974,0.0095,0.5818,0.0594,0.8574,0.1309c1.8618-0.4913,3.3795-1.8293,4.0901-3.5908c-0.4448,0.1034-0.9082,0.1583-1.3844,0.1583c-3.3574,0-6.0792-2.7217-6.0792-6.0792c0-0.8036,0.156-1.571,0.4392-2.2733C13.0043,1.785,11,4.1981,11,7.0791c0,0.1427,0.0117,0.2824,0.0214,0.4227C14.0034,7.5117,16.5847,9.5358,17.3018,12.4331z"/>
-	<path d="M10.9998,8.0002c2.7609,0,5.1527,1.8724,5.8164,4.5533l0.182,0.7351l0.757,0.024c1.8195,0.0577,3.2446,1.5237,3.2446,3.3376c0,1.8472-1.5028,3.35-3.35,3.35h-12.4c-1.2406,0-2.25-1.0094-2.25-2.2501c0-0.921,0.5547-1.7391,1.4131-2.0842l0.6992-0.2811l-0.0775-0.7496c-0.0234-0.2259-0.0348-0.4337-0.0348-0.6351C4.9998,10.6919,7.6914,8.0002,10.9998,8.0002 M10.9998,7.0002c-3.866,0-7,3.1331-7,7.0001c0,0.2501,0.015,0.496,0.04,0.738c-1.194,0.48-2.04,1.6451-2.04,3.012c0,1.794,1.4551,3.2501,3.25,3.2501h12.4c2.403,0,4.35-1.9481,4.35-4.35c0-2.3561-1.875-4.263-4.2129-4.3372C17.0317,9.2633,14.2838,7.0002,10.9998,7.0002L10.9998,7.0002z"/>
-</g>
-<g id="mostly_cloudy">
-	<rect fill="none" width="24" height="24"/>
-	<path d="M10.9998,6.0003c2.7609,0,5.1527,1.8724,5.8164,4.5533l0.182,0.7352l0.7571,0.024c1.8193,0.0576,3.2445,1.5236,3.2445,3.3375c0,1.8472-1.5028,3.35-3.35,3.35h-12.4c-1.2406,0-2.25-1.0093-2.25-2.2499c0-0.921,0.5547-1.7391,1.4131-2.0842l0.6991-0.2811l-0.0774-0.7496c-0.0234-0.2261-0.0348-0.4339-0.0348-0.6351C4.9998,8.6919,7.6914,6.0003,10.9998,6.0003 M10.9998,5.0003c-3.866,0-7,3.133-7,7.0001c0,0.2499,0.015,0.4958,0.04,0.738c-1.194,0.48-2.04,1.6449-2.04,3.012c0,1.794,1.4551,3.2499,3.25,3.2499h12.4c2.403,0,4.35-1.9479,4.35-4.35c0-2.3559-1.875-4.263-4.2129-4.337C17.0317,7.2634,14.2838,5.0003,10.9998,5.0003L10.9998,5.0003z"/>
-</g>
-<g id="partly_cloudy">
-	<use xlink:href="#partly_cloudy_day"/>
-</g>
-<g id="partly_cloudy_day">
-	<rect fill="none" width="24" height="24"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M13.5633,6.5883C14.1184,6.2178,14.7838,6,15.4996,6c1.9297,0,3.5,1.5703,3.5,3.5c0,0.657-0.1931,1.2654-0.5095,1.7914c0.2981,0.1616,0.5735,0.3554,0.8163,0.5865C19.7392,11.1865,20,10.3759,20,9.4999c0-2.4852-2.0148-4.5-4.5-4.5c-1.1035,0-2.101,0.4127-2.8838,1.072C12.9476,6.2183,13.2628,6.3901,13.5633,6.5883z"/>
-	<path d="M9.9998,6.0003c2.7609,0,5.1526,1.8724,5.8163,4.5533l0.182,0.7352l0.7571,0.024c1.8195,0.0576,3.2446,1.5235,3.2446,3.3374c0,1.8472-1.5028,3.35-3.35,3.35h-12.4c-1.2406,0-2.25-1.0093-2.25-2.2499c0-0.921,0.5547-1.7391,1.4131-2.0841l0.6991-0.2811l-0.0774-0.7496c-0.0234-0.2261-0.0348-0.4339-0.0348-0.6352C3.9998,8.6919,6.6914,6.0003,9.9998,6.0003 M9.9998,5.0003c-3.866,0-7,3.1331-7,7c0,0.2499,0.015,0.4959,0.04,0.738c-1.194,0.48-2.04,1.6448-2.04,3.012c0,1.794,1.4551,3.2499,3.25,3.2499h12.4c2.403,0,4.35-1.9479,4.35-4.35c0-2.3559-1.875-4.2629-4.213-4.3369C16.0317,7.2633,13.2838,5.0003,9.9998,5.0003L9.9998,5.0003z"/>
-	<path d="M13.1562,4.3413c-0.1963,0-0.3818-0.1162-0.4619-0.3086L12.1201,2.647c-0.1055-0.2549,0.0156-0.5474,0.2705-0.6533c0.2529-0.1045,0.5469,0.0151,0.6533,0.2705l0.5742,1.3857c0.1055,0.2549-0.0156,0.5474-0.2705,0.6533C13.2852,4.3291,13.2207,4.3413,13.1562,4.3413z"/>
-	<path d="M21.1582,7.6558c-0.1963,0-0.3818-0.1162-0.4619-0.3091c-0.1055-0.2549,0.0156-0.5474,0.2705-0.6528l1.3867-0.5737c0.2578-0.105,0.5479,0.0161,0.6533,0.271s-0.0156,0.5474-0.2705,0.6528l-1.3867,0.5737C21.2871,7.6436,21.2217,7.6558,21.1582,7.6558z"/>
-	<path d="M17.8438,4.3413c-0.0645,0-0.1289-0.0122-0.1914-0.0381c-0.2549-0.106-0.376-0.3984-0.2705-0.6533l0.5742-1.3857c0.1064-0.2554,0.4004-0.375,0.6533-0.2705c0.2549,0.106,0.376,0.3984,0.2705,0.6533l-0.5742,1.3857C18.2256,4.2251,18.04,4.3413,17.8438,4.3413z"/>
-	<path d="M22.5449,12.918c-0.0635,0-0.1289-0.0122-0.1914-0.0381l-1.3867-0.5742c-0.2549-0.1055-0.376-0.3979-0.2705-0.6533c0.1064-0.2544,0.3975-0.3765,0.6533-0.2705l1.3867,0.5742c0.2549,0.1055,0.376,0.3979,0.2705,0.6533C22.9268,12.8018,22.7412,12.918,22.5449,12.918z"/>
-</g>
-<g id="partly_cloudy_night">
-	<rect fill="none" width="24" height="24"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M17.3018,10.4336l0.0908,0.3677l0.3789,0.0117c0.4255,0.0135,0.8248,0.1093,1.2029,0.2466c1.3015-0.1574,2.3879-1.0129,2.8618-2.1874c-0.2594,0.0605-0.5298,0.0925-0.8076,0.0925c-1.9585,0-3.5463-1.5878-3.5463-3.5462c0-0.4689,0.0909-0.9164,0.256-1.326c-1.3716,0.3194-2.4368,1.4349-2.6837,2.8329C16.1385,7.7931,16.9482,9.0055,17.3018,10.4336z"/>
-	<path d="M10.9998,6.0003c2.7609,0,5.1527,1.8724,5.8164,4.5533l0.182,0.7352l0.7571,0.024c1.8193,0.0576,3.2445,1.5236,3.2445,3.3375c0,1.8472-1.5028,3.35-3.35,3.35h-12.4c-1.2406,0-2.25-1.0093-2.25-2.2499c0-0.921,0.5547-1.7391,1.4131-2.0842l0.6991-0.2811l-0.0774-0.7496c-0.0234-0.2261-0.0348-0.4339-0.0348-0.6351C4.9998,8.6919,7.6914,6.0003,10.9998,6.0003 M10.9998,5.0003c-3.866,0-7,3.1331-7,7.0001c0,0.2499,0.015,0.4958,0.04,0.738c-1.194,0.48-2.04,1.6449-2.04,3.012c0,1.794,1.4551,3.2499,3.25,3.2499h12.4c2.403,0,4.35-1.9479,4.35-4.35c0-2.3559-1.875-4.263-4.2129-4.337C17.0317,7.2634,14.2838,5.0003,10.9998,5.0003L10.9998,5.0003z"/>
-</g>
-<g id="rain_heavy">
-	<rect fill="none" width="24" height="24"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M17.2613,22.1666L17.2613,22.1666c-0.2552-0.1059-0.3764-0.3983-0.2705-0.6535l1.6271-3.9261c0.1057-0.2552,0.3981-0.3764,0.6535-0.2707c0.2552,0.1059,0.3762,0.3985,0.2705,0.6536l-1.6271,3.9261C17.8091,22.1513,17.5163,22.2723,17.2613,22.1666 M2.9718,21.897l1.6271-3.9259c0.1057-0.2552-0.0154-0.5478-0.2705-0.6535c-0.2552-0.1057-0.5478,0.0153-0.6535,0.2705l-1.6271,3.9261c-0.1059,0.2552,0.0154,0.5478,0.2705,0.6535S2.8661,22.1524,2.9718,21.897 M6.7075,21.8968l1.6271-3.9261c0.1057-0.2552-0.0154-0.5478-0.2705-0.6535c-0.2552-0.1057-0.5478,0.0154-0.6535,0.2705l-1.6271,3.9263c-0.1057,0.2552,0.0154,0.5476,0.2705,0.6535C6.3092,22.2731,6.6018,22.1522,6.7075,21.8968 M10.4432,21.8965l1.6271-3.9261c0.1059-0.255-0.0154-0.5476-0.2705-0.6533c-0.2552-0.1059-0.5478,0.0154-0.6535,0.2705l-1.6271,3.9261c-0.1057,0.2552,0.0154,0.5478,0.2705,0.6535S10.3375,22.1518,10.4432,21.8965 M14.1789,21.8965l1.6272-3.9263c0.1057-0.2552-0.0155-0.5478-0.2705-0.6535c-0.2554-0.1057-0.5479,0.0153-0.6535,0.2705l-1.6272,3.9263c-0.1057,0.255,0.0155,0.5476,0.2705,0.6535C13.7806,22.2725,14.0734,22.1515,14.1789,21.8965 M16.0469,21.8963l0.3432-0.8289c0.1059-0.2552-0.0152-0.5476-0.2705-0.6535c-0.2552-0.1057-0.5476,0.0155-0.6535,0.2705l-0.3432,0.8289c-0.1059,0.2552,0.0154,0.5476,0.2705,0.6535C15.6486,22.2723,15.9412,22.1513,16.0469,21.8963 M12.3112,21.8965l0.3434-0.8287c0.1055-0.2552-0.0155-0.5478-0.2707-0.6535c-0.2552-0.1059-0.5478,0.0153-0.6535,0.2705l-0.3432,0.8289c-0.1059,0.255,0.0154,0.5476,0.2705,0.6533C11.9127,22.2727,12.2055,22.1516,12.3112,21.8965 M8.5753,21.8967l0.3434-0.8287c0.1057-0.255-0.0154-0.5476-0.2705-0.6535C8.393,20.3088,8.1004,20.43,7.9947,20.6852l-0.3434,0.8287c-0.1057,0.2552,0.0154,0.5478,0.2707,0.6535C8.177,22.2731,8.4696,22.152,8.5753,21.8967 M4.8396,21.8968l0.3434-0.8285c0.1057-0.2552-0.0155-0.5478-0.2707-0.6535c-0.255-0.1059-0.5478,0.0154-0.6535,0.2705l-0.3432,0.8289c-0.1057,0.255,0.0154,0.5476,0.2705,0.6535C4.4415,22.2732,4.7339,22.1522,4.8396,21.8968 M17.0603,19.4508l0.6138-1.4808c0.1055-0.2552-0.0155-0.5478-0.2707-0.6535c-0.2552-0.1057-0.5478,0.0155-0.6533,0.2707l-0.6138,1.4808c-0.1057,0.255,0.0155,0.5478,0.2705,0.6533C16.662,19.8272,16.9548,19.7059,17.0603,19.4508 M13.3246,19.4511l0.6136-1.4808c0.1059-0.2552-0.0154-0.5478-0.2705-0.6535c-0.2554-0.1057-0.5478,0.0153-0.6535,0.2705l-0.6136,1.4808c-0.1059,0.2552,0.0154,0.5479,0.2705,0.6535C12.9265,19.8275,13.2189,19.7063,13.3246,19.4511 M9.5889,19.4513l0.6136-1.4808c0.1057-0.2552-0.0154-0.5478-0.2705-0.6535c-0.2552-0.1057-0.5478,0.0155-0.6535,0.2705l-0.6136,1.481c-0.1059,0.255,0.0154,0.5478,0.2705,0.6535C9.1906,19.8277,9.4832,19.7065,9.5889,19.4513 M5.8532,19.4516l0.6136-1.4808c0.1057-0.2552-0.0155-0.5478-0.2707-0.6535c-0.255-0.1057-0.5478,0.0154-0.6535,0.2705l-0.6134,1.4808c-0.1057,0.2552,0.0154,0.5479,0.2705,0.6535C5.4549,19.8281,5.7475,19.7068,5.8532,19.4516"/>
-	<path d="M10.9994,2.9994c2.7609,0,5.1526,1.8724,5.8163,4.5533l0.182,0.7353l0.7571,0.0239c1.8195,0.0575,3.2446,1.5235,3.2446,3.3374c0,1.8472-1.5028,3.35-3.35,3.35h-12.4c-1.2406,0-2.25-1.0093-2.25-2.2499c0-0.921,0.5547-1.7391,1.4131-2.0842l0.6991-0.2811L5.0342,9.6346C5.0107,9.4085,4.9994,9.2008,4.9994,8.9995C4.9994,5.691,7.691,2.9994,10.9994,2.9994 M10.9994,1.9994c-3.866,0-7,3.1331-7,7.0001c0,0.2499,0.015,0.4959,0.04,0.738c-1.194,0.48-2.04,1.6449-2.04,3.012c0,1.794,1.4551,3.2499,3.25,3.2499h12.4c2.403,0,4.35-1.9479,4.35-4.35c0-2.3559-1.875-4.263-4.213-4.3369C17.0314,4.2625,14.2834,1.9994,10.9994,1.9994L10.9994,1.9994z"/>
-</g>
-<g id="rain_light">
-	<rect fill="none" width="24" height="24"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M11.5342,20.0402c-0.064,0-0.129-0.012-0.192-0.0381c-0.255-0.1059-0.376-0.399-0.27-0.6529l0.478-1.155c0.106-0.256,0.401-0.375,0.654-0.2711c0.255,0.1061,0.376,0.399,0.27,0.654l-0.478,1.1541C11.9162,19.9241,11.7302,20.0402,11.5342,20.0402 M4.5242,19.7312l0.479-1.155c0.105-0.255-0.016-0.5481-0.271-0.6531c-0.253-0.1059-0.547,0.015-0.653,0.27l-0.479,1.1561c-0.105,0.255,0.016,0.5471,0.271,0.6529c0.062,0.0261,0.127,0.0381,0.191,0.0381C4.2582,20.0402,4.4442,19.9241,4.5242,19.7312 M19.4672,19.7312l0.479-1.1541c0.105-0.255-0.016-0.5479-0.271-0.654c-0.253-0.1039-0.547,0.015-0.653,0.2711l-0.478,1.155c-0.106,0.2539,0.015,0.5471,0.27,0.6529c0.063,0.0261,0.128,0.0381,0.191,0.0381C19.2012,20.0402,19.3882,19.9241,19.4672,19.7312 M8.2602,19.7312l0.478-1.1541c0.106-0.255-0.015-0.5479-0.27-0.654c-0.253-0.1039-0.547,0.015-0.653,0.2711l-0.479,1.155c-0.105,0.2539,0.016,0.5471,0.271,0.6529c0.062,0.0261,0.127,0.0381,0.191,0.0381C7.9942,20.0402,8.1802,19.9241,8.2602,19.7312 M15.7322,19.7312l0.478-1.1541c0.106-0.255-0.016-0.5479-0.27-0.654c-0.253-0.1039-0.548,0.015-0.654,0.2711l-0.478,1.155c-0.106,0.2539,0.015,0.5471,0.27,0.6529c0.063,0.0261,0.128,0.0381,0.192,0.0381C15.4652,20.0402,15.6522,19.9241,15.7322,19.7312"/>
-	<path d="M10.9999,2.9999c2.7609,0,5.1526,1.8724,5.8163,4.5533l0.182,0.7352l0.757,0.024c1.8195,0.0577,3.2448,1.5237,3.2448,3.3376c0,1.8472-1.5028,3.35-3.35,3.35h-12.4c-1.2406,0-2.25-1.0094-2.25-2.2501c0-0.921,0.5547-1.7391,1.4131-2.0842l0.6992-0.2811L5.0347,9.6351C5.0112,9.4092,4.9999,9.2014,4.9999,9C4.9999,5.6916,7.6915,2.9999,10.9999,2.9999 M10.9999,1.9999c-3.866,0-7,3.1331-7,7.0001c0,0.2501,0.015,0.4961,0.04,0.738c-1.194,0.48-2.04,1.6451-2.04,3.012c0,1.794,1.4551,3.2501,3.25,3.2501h12.4c2.403,0,4.35-1.9481,4.35-4.35c0-2.356-1.875-4.263-4.213-4.3371C17.0319,4.263,14.2839,1.9999,10.9999,1.9999L10.9999,1.9999z"/>
-</g>
-<g id="rain">
-	<rect fill-rule="evenodd" clip-rule="evenodd" fill="none" width="24" height="24"/>
-	<path d="M10.9998,3c2.761,0,5.1527,1.8724,5.8163,4.5533l0.182,0.7353l0.7571,0.024c1.8195,0.0576,3.2446,1.5236,3.2446,3.3375c0,1.8472-1.5028,3.35-3.35,3.35h-12.4c-1.2406,0-2.25-1.0094-2.25-2.25c0-0.921,0.5547-1.7391,1.4131-2.0842l0.6992-0.2811L5.0345,9.6351C5.0111,9.4091,4.9998,9.2014,4.9998,9C4.9998,5.6916,7.6914,3,10.9998,3 M10.9998,2c-3.866,0-7,3.133-7,7c0,0.25,0.015,0.496,0.04,0.738c-1.194,0.48-2.04,1.645-2.04,3.012c0,1.794,1.4551,3.25,3.25,3.25h12.4c2.4031,0,4.35-1.948,4.35-4.35c0-2.356-1.875-4.263-4.213-4.337C17.0319,4.263,14.2838,2,10.9998,2L10.9998,2z"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M11.5342,19.04c-0.0645,0-0.1289-0.0122-0.1914-0.0381c-0.2549-0.1055-0.376-0.3984-0.2705-0.6533l0.4785-1.1548c0.1064-0.2559,0.4014-0.375,0.6533-0.2705c0.2549,0.1055,0.376,0.3984,0.2705,0.6533l-0.4785,1.1548C11.916,18.9238,11.7305,19.04,11.5342,19.04z M8.2598,18.7314l0.4785-1.1548c0.1055-0.2549-0.0156-0.5479-0.2705-0.6533c-0.2529-0.1045-0.5469,0.0146-0.6533,0.2705l-0.4785,1.1548c-0.1055,0.2549,0.0156,0.5479,0.2705,0.6533c0.0625,0.0259,0.127,0.0381,0.1914,0.0381C7.9941,19.04,8.1797,18.9238,8.2598,18.7314z M9.4062,21.6021l0.4785-1.1548c0.1055-0.2549-0.0156-0.5479-0.2705-0.6533c-0.2539-0.105-0.5469,0.0151-0.6533,0.2705l-0.4785,1.1548c-0.1055,0.2549,0.0156,0.5479,0.2705,0.6533c0.0625,0.0259,0.127,0.0381,0.1914,0.0381C9.1406,21.9106,9.3262,21.7944,9.4062,21.6021z M5.6631,21.6016l0.4775-1.1548c0.1055-0.2549-0.0156-0.5474-0.2705-0.6528c-0.2549-0.106-0.5488,0.0156-0.6533,0.271l-0.4775,1.1548c-0.1055,0.2549,0.0156,0.5474,0.2705,0.6528c0.0625,0.0259,0.1279,0.0381,0.1914,0.0381C5.3975,21.9106,5.584,21.7944,5.6631,21.6016z M4.5244,18.7314l0.4785-1.1553c0.1055-0.2554-0.0156-0.5479-0.2705-0.6533c-0.2559-0.1064-0.5469,0.0156-0.6533,0.2705l-0.4785,1.1553c-0.1055,0.2554,0.0156,0.5479,0.2705,0.6533C3.9336,19.0278,3.999,19.04,4.0625,19.04C4.2588,19.04,4.4443,18.9238,4.5244,18.7314zM19.4678,18.7314l0.4785-1.1548c0.1055-0.2549-0.0156-0.5479-0.2705-0.6533c-0.2529-0.1045-0.5469,0.0146-0.6533,0.2705l-0.4785,1.1548c-0.1055,0.2549,0.0156,0.5479,0.2705,0.6533c0.0625,0.0259,0.127,0.0381,0.1914,0.0381C19.2021,19.04,19.3877,18.9238,19.4678,18.7314z M15.7314,18.7314l0.4785-1.1548c0.1055-0.2549-0.0156-0.5479-0.2705-0.6533c-0.252-0.1045-0.5469,0.0146-0.6533,0.2705l-0.4785,1.1548c-0.1055,0.2549,0.0156,0.5479,0.2705,0.6533c0.0625,0.0259,0.127,0.0381,0.1914,0.0381C15.4658,19.04,15.6514,18.9238,15.7314,18.7314z M16.8945,21.6016l0.4775-1.1548c0.1055-0.2549-0.0156-0.5474-0.2705-0.6528c-0.2549-0.106-0.5479,0.0156-0.6533,0.271l-0.4775,1.1548c-0.1055,0.2549,0.0156,0.5474,0.2705,0.6528c0.0625,0.0259,0.1279,0.0381,0.1914,0.0381C16.6289,21.9106,16.8154,21.7944,16.8945,21.6016z M13.1504,21.6021l0.4785-1.1548c0.1055-0.2549-0.0156-0.5479-0.2705-0.6533c-0.2529-0.105-0.5469,0.0151-0.6533,0.2705l-0.4785,1.1548c-0.1055,0.2549,0.0156,0.5479,0.2705,0.6533c0.0625,0.0259,0.127,0.0381,0.1914,0.0381C12.8848,21.9106,13.0703,21.7944,13.1504,21.6021z"/>
-</g>
-<g id="snow_heavy">
-	<rect x="0" y="0.0001" fill="none" width="24" height="24"/>
-	<path d="M10.9999,1c2.7609,0,5.1526,1.8724,5.8163,4.5533l0.182,0.7352l0.757,0.024c1.8195,0.0577,3.2448,1.5238,3.2448,3.3376c0,1.8472-1.5028,3.35-3.35,3.35h-12.4c-1.2406,0-2.25-1.0094-2.25-2.2501c0-0.921,0.5547-1.7391,1.4131-2.0842l0.6992-0.2811L5.0347,7.6351C5.0112,7.4092,4.9999,7.2015,4.9999,7.0001C4.9999,3.6916,7.6915,1,10.9999,1 M10.9999,0c-3.866,0-7,3.1331-7,7.0001c0,0.2501,0.015,0.4961,0.04,0.738c-1.194,0.48-2.04,1.6451-2.04,3.012c0,1.794,1.4551,3.2501,3.25,3.2501h12.4c2.403,0,4.35-1.9481,4.35-4.35c0-2.3561-1.875-4.263-4.213-4.3372C17.0319,2.2631,14.2839,0,10.9999,0L10.9999,0z"/>
-	<path d="M6.5029,18.2862c-0.0918,0.1602-0.2607,0.25-0.4326,0.25c-0.085,0-0.1709-0.0215-0.25-0.0669l-0.8174-0.4719v0.9435c0,0.2764-0.2236,0.5-0.5,0.5s-0.5-0.2236-0.5-0.5v-0.943l-0.8164,0.4713c-0.0791,0.0454-0.165,0.0669-0.25,0.0669c-0.1719,0-0.3408-0.0898-0.4326-0.25c-0.1387-0.2393-0.0566-0.5449,0.1826-0.6831l0.8167-0.4714l-0.8167-0.4714c-0.2393-0.1382-0.3213-0.4438-0.1826-0.6831c0.1377-0.2388,0.4443-0.3193,0.6826-0.1831l0.8164,0.4713v-0.943c0-0.2764,0.2236-0.5,0.5-0.5s0.5,0.2236,0.5,0.5v0.9435l0.8174-0.4719c0.2383-0.1377,0.5449-0.0562,0.6826,0.1831c0.1387,0.2393,0.0566,0.5449-0.1826,0.6831l-0.8167,0.4714l0.8167,0.4714C6.5596,17.7413,6.6416,18.0469,6.5029,18.2862z M21.3135,17.6031l-0.8167-0.4714l0.8167-0.4714c0.2393-0.1382,0.3213-0.4438,0.1826-0.6831c-0.1377-0.2393-0.4443-0.3208-0.6826-0.1831l-0.8164,0.4713v-0.943c0-0.2764-0.2236-0.5-0.5-0.5s-0.5,0.2236-0.5,0.5v0.9435l-0.8174-0.4719c-0.2373-0.1362-0.5449-0.0557-0.6826,0.1831c-0.1387,0.2393-0.0566,0.5449,0.1826,0.6831l0.8167,0.4714l-0.8167,0.4714c-0.2393,0.1382-0.3213,0.4438-0.1826,0.6831c0.0918,0.1602,0.2607,0.25,0.4326,0.25c0.085,0,0.1709-0.0215,0.25-0.0669l0.8174-0.4719v0.9435c0,0.2764,0.2236,0.5,0.5,0.5s0.5-0.2236,0.5-0.5v-0.943l0.8164,0.4713c0.0791,0.0454,0.165,0.0669,0.25,0.0669c0.1719,0,0.3408-0.0898,0.4326-0.25C21.6348,18.0469,21.5527,17.7413,21.3135,17.6031z M13.8164,17.6031L13,17.1317l0.8164-0.4714c0.2393-0.1382,0.3213-0.4438,0.1826-0.6831c-0.1377-0.2407-0.4443-0.3208-0.6826-0.1831L12.5,16.2654v-0.9431c0-0.2764-0.2236-0.5-0.5-0.5s-0.5,0.2236-0.5,0.5v0.9431l-0.8164-0.4714c-0.2383-0.1362-0.5449-0.0571-0.6826,0.1831c-0.1387,0.2393-0.0566,0.5449,0.1826,0.6831L11,17.1317l-0.8164,0.4714c-0.2393,0.1382-0.3213,0.4438-0.1826,0.6831c0.0918,0.1606,0.2607,0.25,0.4326,0.25c0.085,0,0.1709-0.0215,0.25-0.0669L11.5,17.9979v0.9431c0,0.2764,0.2236,0.5,0.5,0.5s0.5-0.2236,0.5-0.5v-0.9431l0.8164,0.4714c0.0791,0.0454,0.165,0.0669,0.25,0.0669c0.1719,0,0.3408-0.0894,0.4326-0.25C14.1377,18.0469,14.0557,17.7413,13.8164,17.6031z M10.251,19.8306c-0.1367-0.2397-0.4434-0.3203-0.6826-0.1831L8.752,20.1188v-0.943c0-0.2764-0.2236-0.5-0.5-0.5s-0.5,0.2236-0.5,0.5v0.9435l-0.8174-0.4719c-0.2383-0.1357-0.5449-0.0557-0.6826,0.1831c-0.1387,0.2393-0.0566,0.5449,0.1826,0.6831l0.8167,0.4714l-0.8167,0.4714c-0.2393,0.1382-0.3213,0.4438-0.1826,0.6831c0.0918,0.1602,0.2607,0.25,0.4326,0.25c0.085,0,0.1709-0.0215,0.25-0.0669L7.752,21.851v0.9435c0,0.2764,0.2236,0.5,0.5,0.5s0.5-0.2236,0.5-0.5v-0.943l0.8164,0.4713c0.0791,0.0454,0.165,0.0669,0.25,0.0669c0.1719,0,0.3408-0.0898,0.4326-0.25c0.1387-0.2393,0.0566-0.5449-0.1826-0.6831l-0.8167-0.4714l0.8167-0.4714C10.3076,20.3755,10.3896,20.0699,10.251,19.8306z M17.748,19.8306c-0.1377-0.2397-0.4434-0.3203-0.6826-0.1831l-0.8174,0.4719v-0.9435c0-0.2764-0.2236-0.5-0.5-0.5s-0.5,0.2236-0.5,0.5v0.943l-0.8164-0.4713c-0.2393-0.1357-0.5449-0.0557-0.6826,0.1831c-0.1387,0.2393-0.0566,0.5449,0.1826,0.6831l0.8167,0.4714l-0.8167,0.4714c-0.2393,0.1382-0.3213,0.4438-0.1826,0.6831c0.0918,0.1602,0.2607,0.25,0.4326,0.25c0.085,0,0.1709-0.0215,0.25-0.0669l0.8164-0.4713v0.943c0,0.2764,0.2236,0.5,0.5,0.5s0.5-0.2236,0.5-0.5V21.851l0.8174,0.4719c0.0791,0.0454,0.165,0.0669,0.25,0.0669c0.1719,0,0.3408-0.0898,0.4326-0.25c0.1387-0.2393,0.0566-0.5449-0.1826-0.6831l-0.8167-0.4714l0.8167-0.4714C17.8047,20.3755,17.8867,20.0699,17.748,19.8306z"/>
-</g>
-<g id="snow_light">
-	<rect x="0" y="0" fill="none" width="24" height="24"/>
-	<path d="M10.9999,2.9999c2.7609,0,5.1526,1.8724,5.8163,4.5533l0.182,0.7352l0.757,0.024c1.8195,0.0577,3.2448,1.5237,3.2448,3.3376c0,1.8472-1.5028,3.35-3.35,3.35h-12.4c-1.2406,0-2.25-1.0094-2.25-2.2501c0-0.921,0.5547-1.7391,1.4131-2.0842l0.6992-0.2811L5.0347,9.6351C5.0112,9.4092,4.9999,9.2015,4.9999,9C4.9999,5.6916,7.6915,2.9999,10.9999,2.9999 M10.9999,1.9999c-3.866,0-7,3.1331-7,7.0001c0,0.2501,0.015,0.4961,0.04,0.738c-1.194,0.48-2.04,1.6451-2.04,3.012c0,1.794,1.4551,3.2501,3.25,3.2501h12.4c2.403,0,4.35-1.9481,4.35-4.35c0-2.3561-1.875-4.263-4.213-4.3372C17.0319,4.263,14.2839,1.9999,10.9999,1.9999L10.9999,1.9999z"/>
-	<path d="M13.8164,20.603L13,20.1316l0.8164-0.4714c0.2393-0.1382,0.3213-0.4438,0.1826-0.6831c-0.1377-0.2407-0.4443-0.3213-0.6826-0.1831L12.5,19.2654v-0.9431c0-0.2764-0.2236-0.5-0.5-0.5s-0.5,0.2236-0.5,0.5v0.9431l-0.8164-0.4714c-0.2383-0.1367-0.5449-0.0566-0.6826,0.1831c-0.1387,0.2393-0.0566,0.5449,0.1826,0.6831L11,20.1316l-0.8164,0.4714c-0.2393,0.1382-0.3213,0.4438-0.1826,0.6831c0.0918,0.1606,0.2607,0.25,0.4326,0.25c0.085,0,0.1709-0.0215,0.25-0.0669L11.5,20.9978v0.9431c0,0.2764,0.2236,0.5,0.5,0.5s0.5-0.2236,0.5-0.5v-0.9431l0.8164,0.4714c0.0791,0.0454,0.165,0.0669,0.25,0.0669c0.1719,0,0.3408-0.0894,0.4326-0.25C14.1377,21.0469,14.0557,20.7412,13.8164,20.603z"/>
-</g>
-<g id="snow">
-	<rect x="0" y="0" fill="none" width="24" height="24"/>
-	<path d="M10.9999,2.9999c2.7609,0,5.1526,1.8724,5.8163,4.5533l0.182,0.7352l0.757,0.024c1.8195,0.0577,3.2448,1.5238,3.2448,3.3376c0,1.8472-1.5028,3.35-3.35,3.35h-12.4c-1.2406,0-2.25-1.0094-2.25-2.2501c0-0.921,0.5547-1.7391,1.4131-2.0842l0.6992-0.2811L5.0347,9.6351C5.0112,9.4092,4.9999,9.2014,4.9999,9C4.9999,5.6916,7.6915,2.9999,10.9999,2.9999 M10.9999,1.9999c-3.866,0-7,3.1331-7,7.0001c0,0.2501,0.015,0.4961,0.04,0.738c-1.194,0.48-2.04,1.6451-2.04,3.012c0,1.794,1.4551,3.2501,3.25,3.2501h12.4c2.403,0,4.35-1.9481,4.35-4.35c0-2.3561-1.875-4.263-4.213-4.3372C17.0319,4.263,14.2839,1.9999,10.9999,1.9999L10.9999,1.9999z"/>
-	<path d="M6.3198,19.6601l-0.8167,0.4714l0.8167,0.4714c0.2393,0.1382,0.3213,0.4438,0.1826,0.6831c-0.0918,0.1602-0.2607,0.25-0.4326,0.25c-0.085,0-0.1709-0.0215-0.25-0.0669l-0.8164-0.4713v0.943c0,0.2764-0.2236,0.5-0.5,0.5s-0.5-0.2236-0.5-0.5v-0.9435L3.186,21.4692c-0.0791,0.0454-0.165,0.0669-0.25,0.0669c-0.1719,0-0.3408-0.0898-0.4326-0.25c-0.1387-0.2393-0.0566-0.5449,0.1826-0.6831l0.8167-0.4714L2.686,19.6601c-0.2393-0.1382-0.3213-0.4438-0.1826-0.6831c0.1377-0.2388,0.4443-0.3203,0.6826-0.1831l0.8174,0.4719v-0.9435c0-0.2764,0.2236-0.5,0.5-0.5s0.5,0.2236,0.5,0.5v0.943l0.8164-0.4713c0.2383-0.1387,0.5449-0.0566,0.6826,0.1831C6.6411,19.2163,6.5591,19.5219,6.3198,19.6601z M21.314,20.603l-0.8167-0.4714l0.8167-0.4714c0.2393-0.1382,0.3213-0.4438,0.1826-0.6831c-0.1377-0.2397-0.4453-0.3218-0.6826-0.1831l-0.8174,0.4719v-0.9435c0-0.2764-0.2236-0.5-0.5-0.5s-0.5,0.2236-0.5,0.5v0.943l-0.8164-0.4713c-0.2383-0.1372-0.5449-0.0557-0.6826,0.1831c-0.1387,0.2393-0.0566,0.5449,0.1826,0.6831l0.8167,0.4714l-0.8167,0.4714c-0.2393,0.1382-0.3213,0.4438-0.1826,0.6831c0.0918,0.1602,0.2607,0.25,0.4326,0.25c0.085,0,0.1709-0.0215,0.25-0.0669l0.8164-0.4713v0.943c0,0.2764,0.2236,0.5,0.5,0.5s0.5-0.2236,0.5-0.5v-0.9435l0.8174,0.4719c0.0791,0.0454,0.165,0.0669,0.25,0.0669c0.1719,0,0.3408-0.0898,0.4326-0.25C21.6352,21.0468,21.5532,20.7412,21.314,20.603z M13.9995,18.977c-0.1377-0.2397-0.4443-0.3218-0.6826-0.1831l-0.8164,0.4713v-0.943c0-0.2764-0.2236-0.5-0.5-0.5s-0.5,0.2236-0.5,0.5v0.9435l-0.8174-0.4719c-0.2383-0.1372-0.5449-0.0557-0.6826,0.1831c-0.1387,0.2393-0.0566,0.5449,0.1826,0.6831l0.8167,0.4714l-0.8167,0.4714c-0.2393,0.1382-0.3213,0.4438-0.1826,0.6831c0.0918,0.1602,0.2607,0.25,0.4326,0.25c0.085,0,0.1709-0.0215,0.25-0.0669l0.8174-0.4719v0.9435c0,0.2764,0.2236,0.5,0.5,0.5s0.5-0.2236,0.5-0.5v-0.943l0.8164,0.4713c0.0791,0.0454,0.165,0.0669,0.25,0.0669c0.1719,0,0.3408-0.0898,0.4326-0.25c0.1387-0.2393,0.0566-0.5449-0.1826-0.6831l-0.8167-0.4714l0.8167-0.4714C14.0561,19.5219,14.1382,19.2163,13.9995,18.977z"/>
-</g>
-<g id="tstorm">
-	<rect fill="none" width="24" height="24"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M16.7667,22.1674L16.7667,22.1674c-0.2552-0.1059-0.3764-0.3983-0.2705-0.6535l1.6271-3.9261c0.1057-0.2552,0.3981-0.3764,0.6535-0.2707c0.2552,0.1059,0.3762,0.3985,0.2705,0.6536l-1.6271,3.9261C17.3145,22.152,17.0217,22.2731,16.7667,22.1674 M2.9772,21.8977l1.6271-3.926c0.1057-0.2552-0.0154-0.5478-0.2705-0.6535c-0.2552-0.1057-0.5478,0.0154-0.6535,0.2705l-1.6271,3.9261c-0.1059,0.2552,0.0154,0.5478,0.2705,0.6535C2.5789,22.2741,2.8715,22.1531,2.9772,21.8977 M6.7129,21.8975l1.6271-3.9261c0.1057-0.2552-0.0154-0.5478-0.2705-0.6535c-0.2552-0.1057-0.5478,0.0154-0.6535,0.2705l-1.6271,3.9263c-0.1057,0.2552,0.0154,0.5476,0.2705,0.6535C6.3146,22.2738,6.6072,22.1529,6.7129,21.8975 M15.5522,21.897l0.3432-0.8289c0.1059-0.2552-0.0152-0.5476-0.2705-0.6535c-0.2552-0.1057-0.5476,0.0155-0.6535,0.2705l-0.3432,0.8289c-0.1059,0.2552,0.0154,0.5476,0.2705,0.6535C15.1539,22.2731,15.4465,22.152,15.5522,21.897 M8.5807,21.8973l0.3434-0.8287c0.1057-0.255-0.0154-0.5476-0.2705-0.6535c-0.2552-0.1057-0.5478,0.0155-0.6535,0.2707l-0.3434,0.8287c-0.1057,0.2552,0.0154,0.5478,0.2707,0.6535C8.1824,22.2738,8.4749,22.1527,8.5807,21.8973 M4.8449,21.8975l0.3434-0.8285c0.1057-0.2552-0.0155-0.5478-0.2707-0.6535c-0.255-0.1059-0.5478,0.0154-0.6535,0.2705l-0.3432,0.8289c-0.1057,0.255,0.0154,0.5476,0.2705,0.6535C4.4468,22.2739,4.7392,22.1529,4.8449,21.8975 M16.5657,19.4515l0.6138-1.4808c0.1055-0.2552-0.0155-0.5478-0.2707-0.6535c-0.2552-0.1057-0.5478,0.0155-0.6533,0.2707l-0.6138,1.4808c-0.1057,0.255,0.0155,0.5478,0.2705,0.6533C16.1674,19.8279,16.4602,19.7066,16.5657,19.4515 M5.8586,19.4523l0.6136-1.4808c0.1057-0.2552-0.0155-0.5478-0.2707-0.6535c-0.255-0.1057-0.5478,0.0154-0.6535,0.2705l-0.6134,1.4808c-0.1057,0.2552,0.0154,0.5479,0.2705,0.6535C5.4603,19.8288,5.7529,19.7075,5.8586,19.4523"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M15.6378,13.6998h-2.9958l0.5339-3.1912c0.0674-0.4025-0.2429-0.769-0.6509-0.769H9.2763c-0.3258,0-0.6029,0.2375-0.6525,0.5594L7.709,16.2395c-0.0615,0.3997,0.2478,0.7604,0.6523,0.7604h2.5162l-0.0994,0.7466l-0.546,3.9535c-0.0312,0.2256,0.0563,0.4515,0.2314,0.5975c0.1091,0.0795,0.1091,0.0795,0.2322,0.1288c0.2874,0.0958,0.603-0.0162,0.7656-0.2718l4.7332-7.4405C16.4739,14.2747,16.1585,13.6998,15.6378,13.6998z"/>
-	<path d="M17.7869,7.3133c-0.7551-3.0499-3.5031-5.313-6.7871-5.313c-3.866,0-7,3.1331-7,7.0001c0,0.2499,0.015,0.4958,0.04,0.738c-1.194,0.48-2.04,1.6449-2.04,3.012c0,1.794,1.4551,3.2499,3.25,3.2499h1.4844l0.1541-1H5.2498c-1.2406,0-2.25-1.0093-2.25-2.2499c0-0.921,0.5547-1.7391,1.4131-2.0842l0.6991-0.2811L5.0345,9.6355C5.0111,9.4094,4.9998,9.2016,4.9998,9.0004c0-3.3085,2.6917-6.0001,6-6.0001c2.7609,0,5.1527,1.8724,5.8164,4.5533l0.182,0.7352l0.7571,0.0239c1.8193,0.0575,3.2445,1.5235,3.2445,3.3374c0,1.8472-1.5028,3.35-3.35,3.35h-0.4962c-0.0356,0.0847-0.0652,0.1716-0.1155,0.2507l-0.4766,0.7493h1.0883c2.403,0,4.35-1.9479,4.35-4.35C21.9998,9.2944,20.1248,7.3872,17.7869,7.3133z"/>
-	<path fill-rule="evenodd" clip-rule="evenodd" d="M24.0001,8.5821c0-1.851-1.4062-3.3494-3.1597-3.4077c-0.5664-2.3963-2.6272-4.1744-5.0903-4.1744c-1.4375,0-2.7334,0.6107-3.6799,1.592l1.0925,0.3585c0.7173-0.5899,1.6104-0.9507,2.5876-0.9507c1.9502,0,3.6426,1.3999,4.1162,3.4048l0.1768,0.7441l0.7637,0.0254c1.2295,0.0405,2.1934,1.0986,2.1934,2.4082c0,1.0565-0.6414,1.9471-1.5271,2.2752l0.1562,0.9901C22.9954,11.4419,24.0001,10.1431,24.0001,8.5821z"/>
-</g>
-
-<!-- wind arrow -->
-<path id="windarrow" d="M12 0l8 9h-6v15h-4v-15h-6z"/>
-
-<!-- moon phases -->
-<path id="new" d="M48,34c-7.72,0-14,6.28-14,14s6.28,14,14,14s14-6.28,14-14S55.72,34,48,34z"/>
-<path id="waxing_crescent" d="M48,34c-7.72,0-14,6.28-14,14s6.28,14,14,14s14-6.28,14-14S55.72,34,48,34z M48.658,57.967C51.877,55.818,54,52.162,54,48s-2.123-7.818-5.342-9.967C53.864,38.375,58,42.708,58,48S53.864,57.625,48.658,57.967z"/>
-<path id="first_quarter" d="M48,34c-7.72,0-14,6.28-14,14s6.28,14,14,14c7.72,0,14-6.28,14-14S55.72,34,48,34z M48,58V38c5.514,0,10,4.486,10,10S53.514,58,48,58z"/>
-<path id="waxing_gibbous" d="M48,34c-7.72,0-14,6.28-14,14s6.28,14,14,14c7.72,0,14-6.28,14-14S55.72,34,48,34z M48,58c-0.222,0-0.44-0.019-0.658-0.033C44.123,55.818,42,52.162,42,48s2.123-7.818,5.342-9.967C47.56,38.019,47.778,38,48,38c5.514,0,10,4.486,10,10S53.514,58,48,58z"/>
-<path id="full" d="M48,34c-7.72,0-14,6.28-14,14s6.28,14,14,14c7.72,0,14-6.28,14-14S55.72,34,48,34z M48,58c-5.514,0-10-4.486-10-10s4.486-10,10-10c5.514,0,10,4.486,10,10S53.514,58,48,58z"/>
-<path id="waning_gibbous" d="M48,34c-7.72,0-14,6.28-14,14s6.28,14,14,14s14-6.28,14-14S55.72,34,48,34z M38,48c0-5.514,4.486-10,10-10c0.222,0,0.439,0.019,0.658,0.033C51.876,40.182,54,43.838,54,48s-2.124,7.818-5.342,9.967C48.439,57.981,48.222,58,48,58   C42.486,58,38,53.514,38,48z"/>
-<path id="last_quarter" d="M48,34c-7.72,0-14,6.28-14,14s6.28,14,14,14s14-6.28,14-14S55.72,34,48,34z M38,48c0-5.514,4.486-10,10-10v20C42.486,58,38,53.514,38,48z"/>
-<path id="waning_crescent" d="M48,34c-7.72,0-14,6.28-14,14s6.28,14,14,14c7.72,0,14-6.28,14-14S55.72,34,48,34z M38,48c0-5.292,4.136-9.625,9.342-9.967C44.123,40.182,42,43.838,42,48s2.123,7.818,5.342,9.967C42.136,57.625,38,53.292,38,48z"/>
-</defs>
-
-<g transform="translate(32 26) scale(14)">
-	<use xlink:href="#{{.IconOne}}"/>
-</g>
-
-<g transform="translate(40 470) scale(5)">
-	<use xlink:href="#{{.IconTwo}}"/>
-</g>
-<g transform="translate(240 470) scale(5)">
-	<use xlink:href="#{{.IconThree}}"/>
-</g>
-<g transform="translate(440 470) scale(5)">
-	<use xlink:href="#{{.IconFour}}"/>
-</g>
-<g transform="scale(1.0) translate(-30 323)">
-	<use xlink:href="#{{.IconMoon}}"/>
-</g>
-
-<g transform="scale(1.0) translate(350 360) rotate({{.WindDir}}, 12, 12)">
-	<use xlink:href="#windarrow"/>
-</g>
-
-<g font-family="DejaVu Sans">
-	<text style="text-anchor:start;" font-size="35px" y="40" x="410">Currently:</text>
-	<text style="text-anchor:end;" font-size="90px" y="120" x="530">{{.TempNow}}</text>
-	<text style="text-anchor:start;" font-size="50px" y="95" x="525">°F</text>
-	<text style="text-anchor:start;" font-size="35px" y="170" x="410">High:</text>
-	<text style="text-anchor:end;" font-size="90px" y="250" x="530">{{.HighOne}}</text>
-	<text style="text-anchor:start;" font-size="50px" y="225" x="525">°F</text>
-	<text style="text-anchor:start;" font-size="35px" y="300" x="410">Low:</text>
-	<text style="text-anchor:end;" font-size="90px" y="380" x="530">{{.LowOne}}</text>
-	<text style="text-anchor:start;" font-size="50px" y="355" x="525">°F</text>
-
-	<text style="text-anchor:middle;" font-size="30px" y="450" x="100">{{.DayTwo}}</text>
-	<text style="text-anchor:start;" font-size="20px" y="615" x="40">High:</text>
-	<text style="text-anchor:end;" font-size="58px" y="665" x="115">{{.HighTwo}}</text>
-	<text style="text-anchor:start;" font-size="37px" y="651" x="112">°F</text>
-	<text style="text-anchor:start;" font-size="20px" y="695" x="40">Low:</text>
-	<text style="text-anchor:end;" font-size="58px" y="745" x="115">{{.LowTwo}}</text>
-	<text style="text-anchor:start;" font-size="37px" y="731" x="112">°F</text>
-
-	<text style="text-anchor:middle;" font-size="30px" y="450" x="300">{{.DayThree}}</text>
-	<text style="text-anchor:start;" font-size="20px" y="615" x="240">High:</text>
-	<text style="text-anchor:end;" font-size="58px" y="665" x="315">{{.HighThree}}</text>
-	<text style="text-anchor:start;" font-size="37px" y="651" x="312">°F</text>
-	<text style="text-anchor:start;" font-size="20px" y="695" x="240">Low:</text>
-	<text style="text-anchor:end;" font-size="58px" y="745" x="315">{{.LowThree}}</text>
-	<text style="text-anchor:start;" font-size="37px" y="731" x="312">°F</text>
-
-	<text style="text-anchor:middle;" font-size="30px" y="450" x="500">{{.DayFour}}</text>
-	<text style="text-anchor:start;" font-size="20px" y="615" x="440">High:</text>
-	<text style="text-anchor:end;" font-size="58px" y="665" x="515">{{.HighFour}}</text>
-	<text style="text-anchor:start;" font-size="37px" y="651" x="512">°F</text>
-	<text style="text-anchor:start;" font-size="20px" y="695" x="440">Low:</text>
-	<text style="text-anchor:end;" font-size="58px" y="745" x="515">{{.LowFour}}</text>
-	<text style="text-anchor:start;" font-size="37px" y="731" x="512">°F</text>
-
-	<text style="text-anchor:middle;" font-size="15px" y="780" x="300">Powered by ClimaCell | Forecast as of: {{.DateString}}</text>
-</g>
-
-<path d="M10,30 a1,1 0 1,1 30,0z" stroke='black' stroke-width="3" fill="none"/>
-<path d="M260,15 a1,1 0 1,0 30,0z"/>
-<path d="M250,365 l10,0 a1,1 0 1,0 0,-7 m-10,15 l15,0 a1,1 0 1,0 0,-7 m-15,15 l20,0 a1,1 0 1,0 0,-7" stroke='black' stroke-width='3' fill='none'/>
-
-<g font-family="DejaVu Sans">
-	<text style="text-anchor:start;" font-size="20px" y="30" x="50">{{.Sunrise}}</text>
-	<text style="text-anchor:middle;" font-size="8px" y="20" x="190">Location:</text>
-	<text style="text-anchor:middle;" font-size="8px" y="30" x="190">{{.Latitude}},{{.Longitude}}</text>
-	<text style="text-anchor:start;" font-size="20px" y="30" x="300">{{.Sunset}}</text>
-	<text style="text-anchor:start;" font-size="20px" y="380" x="35">{{.MoonPhase}}</text>
-	<text style="text-anchor:start;" font-size="20px" y="380" x="280">{{.WindSpeed}}mph</text>
-</g>
-
-<path d="m200,450,0,300,3,0,0-300-3,0z"/>
-<path d="m400,450,0,300,3,0,0-300-3,0z"/>
-
-</svg>
-`
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it over path, so readers see either the old or the new file in full.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// stubProvider returns forecast, or err when it is set, and counts the
+// calls.
+type stubProvider struct {
+	forecast *Forecast
+	err      error
+	calls    int
+}
+
+func (p *stubProvider) Name() string { return "stub" }
+
+func (p *stubProvider) Forecast(loc Location, units string) (*Forecast, error) {
+	p.calls++
+	return p.forecast, p.err
+}
+
+// newTestGenerator returns a generator drawing the recorded Open-Meteo
+// forecast with the default layout through provider.
+func newTestGenerator(t *testing.T) (*FileGenerator, *stubProvider) {
+	t.Helper()
+	srv, _ := newOpenMeteoStandIn(t)
+	forecast, err := newTestOpenMeteo(srv).Forecast(raleigh(t), UnitsImperial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	device, err := newDevice("test", defaultConfig().Defaults, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rasterizer, err := newRasterizer("builtin", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	layouts, err := loadLayouts("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &stubProvider{forecast: forecast}
+	f := &FileGenerator{id: device.ID}
+	f.configure(&generatorSettings{provider: provider, rasterizer: rasterizer, layout: layouts["default"], device: device})
+	f.settings = f.next.Load().(*generatorSettings)
+	return f, provider
+}
+
+// TestGenFileStale checks that a run drawing the last forecast while the
+// providers fail is told apart from one drawing a new forecast.
+func TestGenFileStale(t *testing.T) {
+	f, provider := newTestGenerator(t)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if fetched, stale, err := f.genFile(true); err != nil || !fetched || stale {
+		t.Fatalf("fresh run: fetched = %v, stale = %v, err = %v", fetched, stale, err)
+	}
+
+	provider.err = errors.New("unavailable")
+	if _, stale, err := f.genFile(true); err == nil || stale {
+		t.Errorf("failed run with a recent forecast: stale = %v, err = %v, want an error", stale, err)
+	}
+
+	f.forecast.FetchedAt = time.Now().Add(-f.settings.device.StaleAfter - time.Minute)
+	if fetched, stale, err := f.genFile(true); err != nil || fetched || !stale {
+		t.Errorf("failed run with an old forecast: fetched = %v, stale = %v, err = %v, want stale", fetched, stale, err)
+	}
+}
+
+// stubAlerts returns alerts and counts the calls.
+type stubAlerts struct {
+	alerts []Alert
+	calls  int
+}
+
+func (s *stubAlerts) Name() string { return "stub" }
+
+func (s *stubAlerts) Alerts(loc Location) ([]Alert, error) {
+	s.calls++
+	return s.alerts, nil
+}
+
+// TestAlertRun checks that an alert run draws the last forecast with fresh
+// alerts instead of asking the providers again.
+func TestAlertRun(t *testing.T) {
+	f, provider := newTestGenerator(t)
+	t.Cleanup(func() { forgetDevice(f.id) })
+	source := &stubAlerts{alerts: []Alert{{Event: "Flood Warning", Severity: "severe", Expires: time.Now().Add(time.Hour)}}}
+	settings := *f.settings
+	settings.alerts = AlertSources{source}
+	f.configure(&settings)
+
+	f.Run()
+	if provider.calls != 1 || source.calls != 1 || !f.alerting {
+		t.Fatalf("%d forecast and %d alert requests, alerting %v; want one each and an alert", provider.calls, source.calls, f.alerting)
+	}
+	success := metricValue(t, lastSuccess.WithLabelValues(f.id))
+
+	source.alerts[0].Event = "Flash Flood Warning"
+	f.lastRun = time.Now().Add(-time.Minute)
+	alertJob{f}.Run()
+	if provider.calls != 1 {
+		t.Errorf("%d forecast requests after an alert run, want 1", provider.calls)
+	}
+	if _, _, alerts := f.Shown(); source.calls != 2 || len(alerts) != 1 || alerts[0].Event != "Flash Flood Warning" {
+		t.Errorf("%d alert requests, shown %+v; want the new alert", source.calls, alerts)
+	}
+	if n := metricValue(t, generations.WithLabelValues(f.id, "alerts")); n != 1 {
+		t.Errorf("%v alert runs, want 1", n)
+	}
+	if got := metricValue(t, lastSuccess.WithLabelValues(f.id)); got != success {
+		t.Errorf("last success moved from %v to %v by an alert run", success, got)
+	}
+
+	f.Run()
+	if provider.calls != 2 {
+		t.Errorf("%d forecast requests after a scheduled run, want 2", provider.calls)
+	}
+}
+
+func metricValue(t *testing.T, m prometheus.Metric) float64 {
+	t.Helper()
+	var pb dto.Metric
+	if err := m.Write(&pb); err != nil {
+		t.Fatal(err)
+	}
+	switch {
+	case pb.Counter != nil:
+		return pb.Counter.GetValue()
+	case pb.Gauge != nil:
+		return pb.Gauge.GetValue()
+	}
+	t.Fatalf("metric %v is neither a counter nor a gauge", m.Desc())
+	return 0
+}
+
+// TestRunStaleMetrics checks that a stale run is counted apart from a fresh
+// one and leaves the last success as it was.
+func TestRunStaleMetrics(t *testing.T) {
+	f, provider := newTestGenerator(t)
+	t.Cleanup(func() { forgetDevice(f.id) })
+
+	f.Run()
+	if n := metricValue(t, generations.WithLabelValues(f.id, "ok")); n != 1 {
+		t.Fatalf("%v ok runs, want 1", n)
+	}
+	success := metricValue(t, lastSuccess.WithLabelValues(f.id))
+	png := metricValue(t, imageBytes.WithLabelValues(f.id, "png"))
+
+	provider.err = errors.New("unavailable")
+	f.forecast.FetchedAt = time.Now().Add(-f.settings.device.StaleAfter - time.Minute)
+	f.Run()
+	if n := metricValue(t, generations.WithLabelValues(f.id, "stale")); n != 1 {
+		t.Errorf("%v stale runs, want 1", n)
+	}
+	if n := metricValue(t, generations.WithLabelValues(f.id, "ok")); n != 1 {
+		t.Errorf("%v ok runs after a stale run, want 1", n)
+	}
+	if got := metricValue(t, lastSuccess.WithLabelValues(f.id)); got != success {
+		t.Errorf("last success moved from %v to %v by a stale run", success, got)
+	}
+	if got := metricValue(t, imageBytes.WithLabelValues(f.id, "png")); got != png {
+		t.Errorf("png size changed from %v to %v by a stale run", png, got)
+	}
+}
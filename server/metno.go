@@ -0,0 +1,344 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const defaultMetNoURL = "https://api.met.no/weatherapi/locationforecast/2.0/complete"
+
+// MetNoProvider implements WeatherProvider using the MET Norway
+// Locationforecast 2.0 API. Its terms of service require a User-Agent
+// identifying the application and forbid requesting data again before the
+// Expires header of the previous response, so responses are cached per
+// location and refreshed with If-Modified-Since.
+type MetNoProvider struct {
+	baseURL   string
+	userAgent string
+	client    *http.Client
+
+	mu    sync.Mutex
+	cache map[pointKey]*metNoCacheEntry
+}
+
+// metNoRetain is how long an expired response is kept for revalidation
+// before it is forgotten.
+const metNoRetain = 24 * time.Hour
+
+type metNoCacheEntry struct {
+	expires      time.Time
+	lastModified string
+	resp         *metNoResponse
+}
+
+func NewMetNoProvider(baseURL, userAgent string) *MetNoProvider {
+	return &MetNoProvider{
+		baseURL:   baseURL,
+		userAgent: userAgent,
+		client:    newHTTPClient(),
+		cache:     make(map[pointKey]*metNoCacheEntry),
+	}
+}
+
+func (p *MetNoProvider) Name() string {
+	return "MET Norway"
+}
+
+type metNoSummary struct {
+	Summary struct {
+		SymbolCode string `json:"symbol_code"`
+	} `json:"summary"`
+	Details struct {
+		AirTemperatureMax          *float64 `json:"air_temperature_max"`
+		AirTemperatureMin          *float64 `json:"air_temperature_min"`
+		PrecipitationAmount        float64  `json:"precipitation_amount"`
+		ProbabilityOfPrecipitation float64  `json:"probability_of_precipitation"`
+	} `json:"details"`
+}
+
+type metNoResponse struct {
+	Properties struct {
+		Timeseries []struct {
+			Time time.Time `json:"time"`
+			Data struct {
+				Instant struct {
+					Details struct {
+						AirTemperature        float64 `json:"air_temperature"`
+						AirPressureAtSeaLevel float64 `json:"air_pressure_at_sea_level"`
+						RelativeHumidity      float64 `json:"relative_humidity"`
+						WindFromDirection     float64 `json:"wind_from_direction"`
+						WindSpeed             float64 `json:"wind_speed"`
+					} `json:"details"`
+				} `json:"instant"`
+				Next1Hours  *metNoSummary `json:"next_1_hours"`
+				Next6Hours  *metNoSummary `json:"next_6_hours"`
+				Next12Hours *metNoSummary `json:"next_12_hours"`
+			} `json:"data"`
+		} `json:"timeseries"`
+	} `json:"properties"`
+}
+
+func (p *MetNoProvider) Forecast(loc Location, units string) (*Forecast, error) {
+	// the terms of service ask for at most four decimals
+	loc = Location{
+		Lat: math.Round(loc.Lat*1e4) / 1e4,
+		Lon: math.Round(loc.Lon*1e4) / 1e4,
+		TZ:  loc.TZ,
+	}
+	resp, err := p.fetch(loc)
+	if err != nil {
+		return nil, fmt.Errorf("error getting met.no forecast: %w", err)
+	}
+	forecast, err := metNoForecast(p.Name(), loc, resp, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	// the API only serves metric data
+	forecast.convert(units)
+	return forecast, nil
+}
+
+// fetch returns the cached response for loc while it has not expired, and
+// otherwise revalidates it with If-Modified-Since.
+func (p *MetNoProvider) fetch(loc Location) (*metNoResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for key, e := range p.cache {
+		if now.Sub(e.expires) > metNoRetain {
+			delete(p.cache, key)
+		}
+	}
+	key := newPointKey(loc)
+	entry := p.cache[key]
+	if entry != nil && now.Before(entry.expires) {
+		logrus.Infof("using cached met.no forecast until %s", entry.expires.Format(time.RFC3339))
+		return entry.resp, nil
+	}
+
+	q := url.Values{}
+	q.Set("lat", strconv.FormatFloat(loc.Lat, 'f', -1, 64))
+	q.Set("lon", strconv.FormatFloat(loc.Lon, 'f', -1, 64))
+	req, err := http.NewRequest(http.MethodGet, p.baseURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", p.userAgent)
+	if entry != nil && entry.lastModified != "" {
+		req.Header.Set("If-Modified-Since", entry.lastModified)
+	}
+
+	logrus.Info("getting met.no forecast data")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	expires, err := http.ParseTime(resp.Header.Get("Expires"))
+	if err != nil {
+		expires = time.Now().Add(30 * time.Minute)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && entry != nil:
+		logrus.Info("met.no forecast not modified")
+		entry.expires = expires
+		return entry.resp, nil
+	case resp.StatusCode != http.StatusOK:
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, &StatusError{
+			StatusCode: resp.StatusCode,
+			msg:        fmt.Sprintf("%s: %s", resp.Status, strings.TrimSpace(string(body))),
+		}
+	}
+
+	var data metNoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+	p.cache[key] = &metNoCacheEntry{
+		expires:      expires,
+		lastModified: resp.Header.Get("Last-Modified"),
+		resp:         &data,
+	}
+	return &data, nil
+}
+
+func metNoForecast(source string, loc Location, resp *metNoResponse, now time.Time) (*Forecast, error) {
+	tz := loc.TZ
+	if tz == nil {
+		tz = time.UTC
+	}
+	series := resp.Properties.Timeseries
+	if len(series) == 0 {
+		return nil, fmt.Errorf("met.no forecast is empty")
+	}
+
+	forecast := &Forecast{
+		Source:    source,
+		FetchedAt: now,
+		Units:     UnitsMetric,
+	}
+
+	// current conditions come from the latest step that has started
+	cur := 0
+	for i, step := range series {
+		if step.Time.After(now) {
+			break
+		}
+		cur = i
+	}
+	instant := series[cur].Data.Instant.Details
+	forecast.Current = Current{
+		Time:          series[cur].Time,
+		Temp:          instant.AirTemperature,
+		FeelsLike:     instant.AirTemperature,
+		Humidity:      instant.RelativeHumidity,
+		WindSpeed:     instant.WindSpeed * 3.6,
+		WindDirection: instant.WindFromDirection,
+		Pressure:      instant.AirPressureAtSeaLevel,
+		MoonPhase:     moonPhaseAt(now),
+	}
+	if s := metNoSymbol(series[cur].Data.Next1Hours, series[cur].Data.Next6Hours); s != "" {
+		icon, variant := metNoIcon(s)
+		forecast.Current.Icon = getWeatherIcon(icon, variant)
+	}
+
+	var noonDistance []time.Duration
+	index := make(map[time.Time]int)
+	for _, step := range series {
+		local := step.Time.In(tz)
+		date := calendarDate(local)
+		i, ok := index[date]
+		temp := step.Data.Instant.Details.AirTemperature
+		if !ok {
+			sunrise, sunset := sunriseSunset(loc, date)
+			forecast.Daily = append(forecast.Daily, Day{
+				Date:      date,
+				High:      temp,
+				Low:       temp,
+				Sunrise:   sunrise,
+				Sunset:    sunset,
+				MoonPhase: moonPhaseAt(step.Time),
+			})
+			noonDistance = append(noonDistance, 24*time.Hour)
+			i = len(forecast.Daily) - 1
+			index[date] = i
+		}
+		day := &forecast.Daily[i]
+		if temp > day.High {
+			day.High = temp
+		}
+		if temp < day.Low {
+			day.Low = temp
+		}
+
+		if next := step.Data.Next1Hours; next != nil {
+			day.Precipitation += next.Details.PrecipitationAmount
+			forecast.Hourly = append(forecast.Hourly, Hour{
+				Time:              step.Time,
+				Temp:              temp,
+				Precipitation:     next.Details.PrecipitationAmount,
+				PrecipProbability: next.Details.ProbabilityOfPrecipitation,
+				WindSpeed:         step.Data.Instant.Details.WindSpeed * 3.6,
+				Icon:              metNoIconID(next.Summary.SymbolCode),
+			})
+		} else if next := step.Data.Next6Hours; next != nil {
+			day.Precipitation += next.Details.PrecipitationAmount
+		}
+		if next := step.Data.Next6Hours; next != nil {
+			if v := next.Details.AirTemperatureMax; v != nil && *v > day.High {
+				day.High = *v
+			}
+			if v := next.Details.AirTemperatureMin; v != nil && *v < day.Low {
+				day.Low = *v
+			}
+		}
+
+		// the day's icon is the symbol closest to local noon
+		noon := time.Date(local.Year(), local.Month(), local.Day(), 12, 0, 0, 0, tz)
+		distance := local.Sub(noon)
+		if distance < 0 {
+			distance = -distance
+		}
+		if s := metNoSymbol(step.Data.Next6Hours, step.Data.Next12Hours, step.Data.Next1Hours); s != "" && distance < noonDistance[i] {
+			noonDistance[i] = distance
+			day.Icon = metNoIconID(s)
+		}
+	}
+	forecast.Current.Sunrise, forecast.Current.Sunset = forecast.Daily[0].Sunrise, forecast.Daily[0].Sunset
+	return forecast, nil
+}
+
+// metNoSymbol returns the first symbol code found in summaries.
+func metNoSymbol(summaries ...*metNoSummary) string {
+	for _, s := range summaries {
+		if s != nil && s.Summary.SymbolCode != "" {
+			return s.Summary.SymbolCode
+		}
+	}
+	return ""
+}
+
+// metNoIconID maps a symbol code onto an icon id without a day/night variant.
+func metNoIconID(symbol string) string {
+	icon, _ := metNoIcon(symbol)
+	return icon
+}
+
+// metNoIcon splits a MET Norway symbol_code such as "partlycloudy_night"
+// into an icon id and the "day" or "night" variant used by getWeatherIcon.
+func metNoIcon(symbol string) (string, string) {
+	variant := "day"
+	if strings.HasSuffix(symbol, "_night") {
+		variant = "night"
+	}
+	s := symbol
+	if i := strings.Index(s, "_"); i >= 0 {
+		s = s[:i]
+	}
+
+	intensity := func(light, normal, heavy string) string {
+		switch {
+		case strings.HasPrefix(s, "heavy"):
+			return heavy
+		case strings.HasPrefix(s, "light"):
+			return light
+		}
+		return normal
+	}
+	switch {
+	case s == "clearsky":
+		return "clear", variant
+	case s == "fair":
+		return "mostly_clear", variant
+	case s == "partlycloudy":
+		return "partly_cloudy", variant
+	case s == "cloudy":
+		return "cloudy", variant
+	case s == "fog":
+		return "fog", variant
+	case strings.Contains(s, "thunder"):
+		return "tstorm", variant
+	case strings.Contains(s, "sleet"):
+		return intensity("ice_pellets_light", "ice_pellets", "ice_pellets_heavy"), variant
+	case strings.Contains(s, "snow"):
+		return intensity("snow_light", "snow", "snow_heavy"), variant
+	case strings.Contains(s, "rain"):
+		return intensity("rain_light", "rain", "rain_heavy"), variant
+	}
+	return "cloudy", variant
+}
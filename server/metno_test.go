@@ -0,0 +1,174 @@
+package main
+
+import (
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// metNoLastModified is the Last-Modified of the recorded forecast.
+const metNoLastModified = "Fri, 17 Oct 2025 09:41:52 GMT"
+
+// metNoStandIn serves the recorded MET Norway forecast for Raleigh, NC like
+// the API: with an Expires header, and 304 to a request whose
+// If-Modified-Since matches the recording.
+type metNoStandIn struct {
+	*httptest.Server
+
+	mu sync.Mutex
+	// expires is sent as the Expires header.
+	expires time.Time
+	// requests counts the requests, notModified the 304s answered.
+	requests    int
+	notModified int
+	// ifModifiedSince is the header of the last request.
+	ifModifiedSince string
+}
+
+func newMetNoStandIn(t *testing.T) *metNoStandIn {
+	t.Helper()
+	body, err := ioutil.ReadFile("testdata/metno/complete.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &metNoStandIn{expires: time.Now().Add(30 * time.Minute)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests++
+		s.ifModifiedSince = r.Header.Get("If-Modified-Since")
+		if r.Header.Get("User-Agent") == "" {
+			http.Error(w, "a User-Agent is required", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Expires", s.expires.UTC().Format(http.TimeFormat))
+		w.Header().Set("Last-Modified", metNoLastModified)
+		if s.ifModifiedSince == metNoLastModified {
+			s.notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *metNoStandIn) stats() (requests, notModified int, ifModifiedSince string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests, s.notModified, s.ifModifiedSince
+}
+
+func TestMetNoForecast(t *testing.T) {
+	srv := newMetNoStandIn(t)
+	p := NewMetNoProvider(srv.URL, "kindle-weather-test")
+	loc := raleigh(t)
+	resp, err := p.fetch(loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 10, 17, 10, 30, 0, 0, time.UTC)
+	f, err := metNoForecast(p.Name(), loc, resp, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.validate(raleigh(t)); err != nil {
+		t.Fatalf("forecast is incomplete: %v", err)
+	}
+
+	c := f.Current
+	if c.Temp != 14 || c.Pressure != 1017.3 || math.Abs(c.WindSpeed-3.4*3.6) > 1e-9 || c.Icon != "partly_cloudy_day" {
+		t.Errorf("current = %+v", c)
+	}
+	if !c.Time.Equal(time.Date(2025, 10, 17, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("current is for %s, want the step that started last", c.Time)
+	}
+	if c.Sunrise.IsZero() || !c.Sunrise.Before(c.Sunset) {
+		t.Errorf("sunrise %s, sunset %s", c.Sunrise, c.Sunset)
+	}
+	if len(f.Hourly) < 24 {
+		t.Errorf("%d hours, want at least 24", len(f.Hourly))
+	}
+	// the recording runs to the 23rd; days are calendar dates
+	if len(f.Daily) != 7 {
+		t.Fatalf("%d days, want 7", len(f.Daily))
+	}
+	for i, d := range f.Daily {
+		if want := time.Date(2025, 10, 17+i, 0, 0, 0, 0, time.UTC); !d.Date.Equal(want) {
+			t.Errorf("day %d is %s, want %s", i, d.Date, want)
+		}
+		if d.Low > d.High || d.Icon == "" {
+			t.Errorf("day %d = %+v", i, d)
+		}
+	}
+}
+
+// TestMetNoCache checks the terms of service: no request before the last
+// response expires, then a conditional one.
+func TestMetNoCache(t *testing.T) {
+	srv := newMetNoStandIn(t)
+	p := NewMetNoProvider(srv.URL, "kindle-weather-test")
+
+	// each device and reload loads its own timezone, and the coordinates
+	// are rounded to four decimals
+	first, err := p.fetch(raleigh(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nearby := raleigh(t)
+	nearby.Lat += 1e-6
+	for _, loc := range []Location{raleigh(t), nearby} {
+		if _, err := p.Forecast(loc, UnitsMetric); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if requests, _, ims := srv.stats(); requests != 1 || ims != "" {
+		t.Errorf("%d requests before the response expired, want 1", requests)
+	}
+	if len(p.cache) != 1 {
+		t.Errorf("%d responses cached, want 1", len(p.cache))
+	}
+
+	// once expired the response is revalidated and kept when not modified
+	key := newPointKey(raleigh(t))
+	p.cache[key].expires = time.Now().Add(-time.Minute)
+	srv.mu.Lock()
+	srv.expires = time.Now().Add(time.Hour)
+	srv.mu.Unlock()
+	resp, err := p.fetch(raleigh(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests, notModified, ims := srv.stats()
+	if requests != 2 || notModified != 1 || ims != metNoLastModified {
+		t.Errorf("%d requests, %d not modified, If-Modified-Since %q; want a conditional request", requests, notModified, ims)
+	}
+	if resp != first {
+		t.Error("the cached response was not served after 304")
+	}
+	if exp := p.cache[key].expires; time.Until(exp) < 59*time.Minute {
+		t.Errorf("expires %s, want the 304's", exp)
+	}
+	if _, err := p.fetch(raleigh(t)); err != nil {
+		t.Fatal(err)
+	}
+	if requests, _, _ := srv.stats(); requests != 2 {
+		t.Errorf("%d requests after the 304, want none before it expires", requests)
+	}
+
+	// responses long expired are forgotten
+	p.cache[key].expires = time.Now().Add(-metNoRetain - time.Minute)
+	oslo := Location{Lat: 59.9139, Lon: 10.7522, TZ: time.UTC}
+	if _, err := p.fetch(oslo); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.cache[key]; ok || len(p.cache) != 1 {
+		t.Errorf("%d responses cached, want only the new one", len(p.cache))
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics are served at /metrics for Prometheus.
+var (
+	providerRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kindle_weather_provider_requests_total",
+		Help: "Forecast requests to each provider by status: ok, the HTTP status code of an API error, incomplete when data was missing, or error.",
+	}, []string{"provider", "status"})
+	providerDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kindle_weather_provider_request_duration_seconds",
+		Help:    "Time taken by forecast requests to each provider.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"provider"})
+	generations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kindle_weather_generations_total",
+		Help: "Image generation runs by result: ok, alerts when only the alerts were refreshed, stale when the last forecast was drawn again, or the failure kind.",
+	}, []string{"device", "result"})
+	stageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kindle_weather_generate_stage_duration_seconds",
+		Help:    "Time taken by each stage of image generation: fetch, template, rasterize and compress.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"device", "stage"})
+	lastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kindle_weather_last_success_timestamp_seconds",
+		Help: "Unix time at which the device's image was last generated from a new forecast.",
+	}, []string{"device"})
+	imageBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kindle_weather_image_bytes",
+		Help: "Size of the device's last image generated from a new forecast by format.",
+	}, []string{"device", "format"})
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kindle_weather_http_requests_total",
+		Help: "HTTP requests by device, endpoint and status code. device is empty when the request was not for a configured device.",
+	}, []string{"device", "endpoint", "code"})
+)
+
+func init() {
+	prometheus.MustRegister(providerRequests, providerDuration, generations, stageDuration, lastSuccess, imageBytes, httpRequests)
+}
+
+// providerStatus returns the status label of a provider request that
+// returned err.
+func providerStatus(err error) string {
+	var statusErr *StatusError
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.As(err, &statusErr):
+		return strconv.Itoa(statusErr.StatusCode)
+	}
+	return "error"
+}
+
+// stageTimer observes the duration of consecutive generation stages.
+type stageTimer struct {
+	device string
+	start  time.Time
+}
+
+func newStageTimer(device string) *stageTimer {
+	return &stageTimer{device: device, start: time.Now()}
+}
+
+// done records the time since the previous stage ended as stage.
+func (t *stageTimer) done(stage string) {
+	now := time.Now()
+	stageDuration.WithLabelValues(t.device, stage).Observe(now.Sub(t.start).Seconds())
+	t.start = now
+}
+
+// forgetDevice drops the metrics of a removed device.
+func forgetDevice(id string) {
+	labels := prometheus.Labels{"device": id}
+	for _, vec := range []*prometheus.MetricVec{
+		generations.MetricVec, stageDuration.MetricVec, lastSuccess.MetricVec, imageBytes.MetricVec, httpRequests.MetricVec,
+	} {
+		vec.DeletePartialMatch(labels)
+	}
+}
+
+// statusRecorder remembers the status code written to a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// instrument counts the requests h serves as endpoint.
+func (s *Server) instrument(endpoint string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		h(rec, r)
+		httpRequests.WithLabelValues(s.requestDevice(endpoint, r), endpoint, strconv.Itoa(rec.status)).Inc()
+	}
+}
+
+// requestDevice returns the id of the configured device a request was for,
+// or "".
+func (s *Server) requestDevice(endpoint string, r *http.Request) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if endpoint == "output" {
+		if f, ok := s.outputs[s.outputDir(r.URL.Path)]; ok {
+			return f.id
+		}
+		return ""
+	}
+	id := r.URL.Query().Get("device")
+	if id == "" && s.cfg != nil && len(s.cfg.Devices) == 0 {
+		id = "default"
+	}
+	if _, ok := s.generators[id]; ok {
+		return id
+	}
+	return ""
+}
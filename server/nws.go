@@ -0,0 +1,440 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const defaultNWSURL = "https://api.weather.gov"
+
+// NWSProvider implements WeatherProvider using the US National Weather
+// Service API. The API only covers US locations and requires a User-Agent
+// identifying the application and a contact.
+type NWSProvider struct {
+	baseURL   string
+	userAgent string
+	client    *http.Client
+
+	mu     sync.Mutex
+	points map[pointKey]nwsPointEntry
+}
+
+// nwsPointEntry is a resolved gridpoint and when it was resolved.
+type nwsPointEntry struct {
+	point    *nwsPoint
+	resolved time.Time
+}
+
+// nwsPointMaxAge is how long a resolved gridpoint is used before it is
+// resolved again.
+const nwsPointMaxAge = 24 * time.Hour
+
+func NewNWSProvider(baseURL, userAgent string) *NWSProvider {
+	return &NWSProvider{
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		userAgent: userAgent,
+		client:    newHTTPClient(),
+		points:    make(map[pointKey]nwsPointEntry),
+	}
+}
+
+func (p *NWSProvider) Name() string {
+	return "National Weather Service"
+}
+
+type nwsPoint struct {
+	Forecast            string `json:"forecast"`
+	ForecastHourly      string `json:"forecastHourly"`
+	ObservationStations string `json:"observationStations"`
+}
+
+type nwsPeriod struct {
+	StartTime                  time.Time `json:"startTime"`
+	IsDaytime                  bool      `json:"isDaytime"`
+	Temperature                float64   `json:"temperature"`
+	TemperatureUnit            string    `json:"temperatureUnit"`
+	WindSpeed                  string    `json:"windSpeed"`
+	WindDirection              string    `json:"windDirection"`
+	Icon                       string    `json:"icon"`
+	ShortForecast              string    `json:"shortForecast"`
+	ProbabilityOfPrecipitation nwsValue  `json:"probabilityOfPrecipitation"`
+}
+
+type nwsValue struct {
+	UnitCode string   `json:"unitCode"`
+	Value    *float64 `json:"value"`
+}
+
+type nwsObservation struct {
+	Timestamp          time.Time `json:"timestamp"`
+	TextDescription    string    `json:"textDescription"`
+	Icon               string    `json:"icon"`
+	Temperature        nwsValue  `json:"temperature"`
+	WindDirection      nwsValue  `json:"windDirection"`
+	WindSpeed          nwsValue  `json:"windSpeed"`
+	BarometricPressure nwsValue  `json:"barometricPressure"`
+	RelativeHumidity   nwsValue  `json:"relativeHumidity"`
+	HeatIndex          nwsValue  `json:"heatIndex"`
+	WindChill          nwsValue  `json:"windChill"`
+}
+
+func (p *NWSProvider) Forecast(loc Location, units string) (*Forecast, error) {
+	point, err := p.point(loc)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving nws gridpoint: %w", err)
+	}
+
+	logrus.Info("getting nws forecast data")
+	var daily struct {
+		Properties struct {
+			Periods []nwsPeriod `json:"periods"`
+		} `json:"properties"`
+	}
+	if err := p.get(point.Forecast, &daily); err != nil {
+		return nil, fmt.Errorf("error getting nws forecast: %w", err)
+	}
+
+	logrus.Info("getting nws hourly forecast data")
+	var hourly struct {
+		Properties struct {
+			Periods []nwsPeriod `json:"periods"`
+		} `json:"properties"`
+	}
+	if err := p.get(point.ForecastHourly, &hourly); err != nil {
+		return nil, fmt.Errorf("error getting nws hourly forecast: %w", err)
+	}
+	if len(hourly.Properties.Periods) == 0 || len(daily.Properties.Periods) == 0 {
+		return nil, fmt.Errorf("nws forecast is empty")
+	}
+
+	now := time.Now()
+	forecast := &Forecast{
+		Source:    p.Name(),
+		FetchedAt: now,
+		Units:     UnitsImperial,
+	}
+	for _, h := range hourly.Properties.Periods {
+		hour := Hour{
+			Time:      h.StartTime,
+			Temp:      nwsTemperature(h),
+			WindSpeed: nwsWindSpeed(h.WindSpeed),
+			Icon:      nwsIcon(h.Icon, h.ShortForecast),
+		}
+		if h.ProbabilityOfPrecipitation.Value != nil {
+			hour.PrecipProbability = *h.ProbabilityOfPrecipitation.Value
+		}
+		forecast.Hourly = append(forecast.Hourly, hour)
+	}
+	forecast.Daily = nwsDays(daily.Properties.Periods, forecast.Hourly)
+
+	first := hourly.Properties.Periods[0]
+	forecast.Current = Current{
+		Time:          first.StartTime,
+		Temp:          nwsTemperature(first),
+		FeelsLike:     nwsTemperature(first),
+		WindSpeed:     nwsWindSpeed(first.WindSpeed),
+		WindDirection: compassDegrees(first.WindDirection),
+		Icon:          nwsIcon(first.Icon, first.ShortForecast),
+		MoonPhase:     moonPhaseAt(now),
+	}
+	if obs, err := p.latestObservation(point); err != nil {
+		logrus.Infof("using hourly forecast for current conditions: %v", err)
+	} else {
+		applyNWSObservation(&forecast.Current, obs)
+	}
+
+	// the NWS API has no astronomical data
+	for i := range forecast.Daily {
+		forecast.Daily[i].Sunrise, forecast.Daily[i].Sunset = sunriseSunset(loc, forecast.Daily[i].Date)
+	}
+	forecast.Current.Sunrise, forecast.Current.Sunset = sunriseSunset(loc, forecast.Daily[0].Date)
+	// periods are parsed into US units whatever units the API used
+	forecast.convert(units)
+	return forecast, nil
+}
+
+// point resolves loc to its forecast gridpoint. The result is cached for
+// nwsPointMaxAge since gridpoints rarely change.
+func (p *NWSProvider) point(loc Location) (*nwsPoint, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for key, e := range p.points {
+		if now.Sub(e.resolved) > nwsPointMaxAge {
+			delete(p.points, key)
+		}
+	}
+	key := newPointKey(loc)
+	if e, ok := p.points[key]; ok {
+		return e.point, nil
+	}
+
+	logrus.Info("resolving nws gridpoint")
+	var resp struct {
+		Properties nwsPoint `json:"properties"`
+	}
+	path := fmt.Sprintf("/points/%s,%s", strconv.FormatFloat(loc.Lat, 'f', 4, 64), strconv.FormatFloat(loc.Lon, 'f', 4, 64))
+	if err := p.get(path, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Properties.Forecast == "" || resp.Properties.ForecastHourly == "" {
+		return nil, fmt.Errorf("no forecast available for %v,%v", loc.Lat, loc.Lon)
+	}
+	p.points[key] = nwsPointEntry{point: &resp.Properties, resolved: now}
+	return &resp.Properties, nil
+}
+
+func (p *NWSProvider) latestObservation(point *nwsPoint) (*nwsObservation, error) {
+	if point.ObservationStations == "" {
+		return nil, fmt.Errorf("no observation stations for gridpoint")
+	}
+	var stations struct {
+		Features []struct {
+			ID string `json:"id"`
+		} `json:"features"`
+	}
+	if err := p.get(point.ObservationStations, &stations); err != nil {
+		return nil, err
+	}
+	if len(stations.Features) == 0 {
+		return nil, fmt.Errorf("no observation stations for gridpoint")
+	}
+
+	logrus.Info("getting nws latest observation")
+	var obs struct {
+		Properties nwsObservation `json:"properties"`
+	}
+	if err := p.get(stations.Features[0].ID+"/observations/latest", &obs); err != nil {
+		return nil, err
+	}
+	if obs.Properties.Temperature.Value == nil {
+		return nil, fmt.Errorf("latest observation has no temperature")
+	}
+	return &obs.Properties, nil
+}
+
+// get fetches an API resource. Absolute links returned by the API are
+// re-rooted on baseURL so that a local stand-in can serve every request.
+func (p *NWSProvider) get(link string, v interface{}) error {
+	path := link
+	if u, err := url.Parse(link); err == nil && u.IsAbs() {
+		path = u.RequestURI()
+	}
+	req, err := http.NewRequest(http.MethodGet, p.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", p.userAgent)
+	req.Header.Set("Accept", "application/geo+json")
+	return doJSON(p.client, req, v)
+}
+
+func applyNWSObservation(c *Current, obs *nwsObservation) {
+	c.Time = obs.Timestamp
+	c.Temp = celsiusToFahrenheit(*obs.Temperature.Value)
+	c.FeelsLike = c.Temp
+	if v := obs.HeatIndex.Value; v != nil {
+		c.FeelsLike = celsiusToFahrenheit(*v)
+	} else if v := obs.WindChill.Value; v != nil {
+		c.FeelsLike = celsiusToFahrenheit(*v)
+	}
+	if v := obs.WindSpeed.Value; v != nil {
+		c.WindSpeed = kphToMph(*v)
+	}
+	if v := obs.WindDirection.Value; v != nil {
+		c.WindDirection = *v
+	}
+	if v := obs.BarometricPressure.Value; v != nil {
+		c.Pressure = hPaToInHg(*v / 100)
+	}
+	if v := obs.RelativeHumidity.Value; v != nil {
+		c.Humidity = *v
+	}
+	if icon := nwsIcon(obs.Icon, obs.TextDescription); obs.Icon != "" || obs.TextDescription != "" {
+		c.Icon = icon
+	}
+}
+
+// nwsDays folds the 12 hour day/night forecast periods into days. The
+// daytime period supplies the high and the icon, the following night the
+// low. When the forecast starts in the evening, today's high falls back to
+// the hourly forecast.
+func nwsDays(periods []nwsPeriod, hourly []Hour) []Day {
+	var days []Day
+	index := make(map[time.Time]int)
+	hasLow := make(map[int]bool)
+	for _, period := range periods {
+		date := calendarDate(period.StartTime)
+		i, ok := index[date]
+		if !ok {
+			days = append(days, Day{Date: date, MoonPhase: moonPhaseAt(period.StartTime)})
+			i = len(days) - 1
+			index[date] = i
+		}
+		temp := nwsTemperature(period)
+		if period.IsDaytime {
+			days[i].High = temp
+			days[i].Icon = nwsIcon(period.Icon, period.ShortForecast)
+			continue
+		}
+		days[i].Low = temp
+		hasLow[i] = true
+		if days[i].Icon == "" {
+			days[i].Icon = nwsIcon(period.Icon, period.ShortForecast)
+			days[i].High = temp
+			for _, h := range hourly {
+				if calendarDate(h.Time) == date && h.Temp > days[i].High {
+					days[i].High = h.Temp
+				}
+			}
+		}
+	}
+	if n := len(days); n > 0 && !hasLow[n-1] {
+		// the last day usually lacks its night period
+		days[n-1].Low = days[n-1].High
+	}
+	return days
+}
+
+func nwsTemperature(period nwsPeriod) float64 {
+	if strings.EqualFold(period.TemperatureUnit, "C") {
+		return celsiusToFahrenheit(period.Temperature)
+	}
+	return period.Temperature
+}
+
+var windSpeedRe = regexp.MustCompile(`[0-9]+`)
+
+// nwsWindSpeed parses forecast wind speeds such as "10 mph" or "5 to 10 mph",
+// using the upper bound of a range.
+func nwsWindSpeed(s string) float64 {
+	matches := windSpeedRe.FindAllString(s, -1)
+	if len(matches) == 0 {
+		return 0
+	}
+	v, _ := strconv.ParseFloat(matches[len(matches)-1], 64)
+	if strings.Contains(s, "km/h") {
+		return kphToMph(v)
+	}
+	return v
+}
+
+var compassPoints = []string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+
+// compassDegrees converts a 16-point compass direction to degrees.
+func compassDegrees(dir string) float64 {
+	for i, p := range compassPoints {
+		if strings.EqualFold(dir, p) {
+			return float64(i) * 22.5
+		}
+	}
+	return 0
+}
+
+var nwsIconCodes = map[string]string{
+	"skc":             "clear",
+	"wind_skc":        "clear",
+	"hot":             "clear",
+	"cold":            "clear",
+	"few":             "mostly_clear",
+	"wind_few":        "mostly_clear",
+	"sct":             "partly_cloudy",
+	"wind_sct":        "partly_cloudy",
+	"bkn":             "mostly_cloudy",
+	"wind_bkn":        "mostly_cloudy",
+	"ovc":             "cloudy",
+	"wind_ovc":        "cloudy",
+	"snow":            "snow",
+	"blizzard":        "snow_heavy",
+	"rain_snow":       "ice_pellets",
+	"rain_sleet":      "ice_pellets",
+	"snow_sleet":      "ice_pellets",
+	"sleet":           "ice_pellets",
+	"fzra":            "freezing_rain",
+	"rain_fzra":       "freezing_rain",
+	"snow_fzra":       "freezing_rain",
+	"rain":            "rain",
+	"rain_showers":    "rain",
+	"rain_showers_hi": "rain_light",
+	"tsra":            "tstorm",
+	"tsra_sct":        "tstorm",
+	"tsra_hi":         "tstorm",
+	"tornado":         "tstorm",
+	"hurricane":       "tstorm",
+	"tropical_storm":  "tstorm",
+	"fog":             "fog",
+	"dust":            "fog_light",
+	"smoke":           "fog_light",
+	"haze":            "fog_light",
+}
+
+// nwsIcon maps an NWS icon url such as
+// https://api.weather.gov/icons/land/day/rain_showers,60/tsra,80?size=medium
+// onto an icon id, falling back to the short forecast text.
+func nwsIcon(iconURL, text string) string {
+	if u, err := url.Parse(iconURL); err == nil {
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(parts) >= 4 {
+			code := strings.SplitN(parts[3], ",", 2)[0]
+			if icon, ok := nwsIconCodes[code]; ok {
+				return icon
+			}
+		}
+	}
+	return forecastTextIcon(text)
+}
+
+// forecastTextIcon maps free-form forecast text such as "Chance Light Rain"
+// onto an icon id.
+func forecastTextIcon(text string) string {
+	t := strings.ToLower(text)
+	intensity := func(light, normal, heavy string) string {
+		switch {
+		case strings.Contains(t, "heavy"):
+			return heavy
+		case strings.Contains(t, "light"), strings.Contains(t, "slight"), strings.Contains(t, "chance"):
+			return light
+		}
+		return normal
+	}
+	switch {
+	case strings.Contains(t, "thunder"), strings.Contains(t, "t-storm"):
+		return "tstorm"
+	case strings.Contains(t, "freezing drizzle"):
+		return "freezing_drizzle"
+	case strings.Contains(t, "freezing"):
+		return intensity("freezing_rain_light", "freezing_rain", "freezing_heavy_rain")
+	case strings.Contains(t, "sleet"), strings.Contains(t, "ice pellets"):
+		return intensity("ice_pellets_light", "ice_pellets", "ice_pellets_heavy")
+	case strings.Contains(t, "flurries"):
+		return "flurries"
+	case strings.Contains(t, "snow"), strings.Contains(t, "blizzard"):
+		return intensity("snow_light", "snow", "snow_heavy")
+	case strings.Contains(t, "drizzle"):
+		return "drizzle"
+	case strings.Contains(t, "rain"), strings.Contains(t, "showers"):
+		return intensity("rain_light", "rain", "rain_heavy")
+	case strings.Contains(t, "fog"):
+		return "fog"
+	case strings.Contains(t, "haze"), strings.Contains(t, "smoke"), strings.Contains(t, "mist"):
+		return "fog_light"
+	case strings.Contains(t, "partly"):
+		return "partly_cloudy"
+	case strings.Contains(t, "mostly cloudy"):
+		return "mostly_cloudy"
+	case strings.Contains(t, "mostly sunny"), strings.Contains(t, "mostly clear"), strings.Contains(t, "fair"):
+		return "mostly_clear"
+	case strings.Contains(t, "sunny"), strings.Contains(t, "clear"):
+		return "clear"
+	}
+	return "cloudy"
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// nwsStandIn serves the recorded NWS responses for Raleigh, NC. The links in
+// the recordings point at api.weather.gov; the provider re-roots them on the
+// stand-in.
+type nwsStandIn struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests map[string]int
+}
+
+func newNWSStandIn(t *testing.T) *nwsStandIn {
+	t.Helper()
+	files := map[string]string{
+		"/points/35.7800,-78.6400":              "points.json",
+		"/gridpoints/RAH/73,57/forecast":        "forecast.json",
+		"/gridpoints/RAH/73,57/forecast/hourly": "forecast_hourly.json",
+		"/gridpoints/RAH/73,57/stations":        "stations.json",
+		"/stations/KRDU/observations/latest":    "observation.json",
+	}
+	bodies := make(map[string][]byte)
+	for path, name := range files {
+		body, err := ioutil.ReadFile("testdata/nws/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bodies[path] = body
+	}
+
+	s := &nwsStandIn{requests: make(map[string]int)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		s.mu.Unlock()
+		if r.Header.Get("User-Agent") == "" {
+			http.Error(w, "a User-Agent is required", http.StatusForbidden)
+			return
+		}
+		body, ok := bodies[r.URL.Path]
+		if !ok {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"type":"https://api.weather.gov/problems/InvalidPoint","title":"Invalid Point","status":404,"detail":"Unable to provide data for requested point"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/geo+json")
+		w.Write(body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *nwsStandIn) count(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+func TestNWSForecast(t *testing.T) {
+	srv := newNWSStandIn(t)
+	loc := raleigh(t)
+
+	f, err := NewNWSProvider(srv.URL, "kindle-weather-test").Forecast(loc, UnitsImperial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.validate(raleigh(t)); err != nil {
+		t.Fatalf("forecast is incomplete: %v", err)
+	}
+	for _, path := range []string{
+		"/points/35.7800,-78.6400",
+		"/gridpoints/RAH/73,57/forecast",
+		"/gridpoints/RAH/73,57/forecast/hourly",
+		"/stations/KRDU/observations/latest",
+	} {
+		if n := srv.count(path); n != 1 {
+			t.Errorf("%s requested %d times, want 1", path, n)
+		}
+	}
+
+	// Current conditions come from the KRDU observation, in metric units.
+	c := f.Current
+	if want := time.Date(2025, 10, 17, 13, 51, 0, 0, time.UTC); !c.Time.Equal(want) {
+		t.Errorf("current time = %v, want %v", c.Time, want)
+	}
+	if math.Abs(c.Temp-60.08) > 0.01 || c.FeelsLike != c.Temp {
+		t.Errorf("temp, feels like = %v, %v, want 60.08°F", c.Temp, c.FeelsLike)
+	}
+	// 11.16 km/h and 101930 Pa
+	if math.Abs(c.WindSpeed-6.93) > 0.01 || c.WindDirection != 220 || c.Humidity != 61.8 {
+		t.Errorf("current = %+v", c)
+	}
+	if math.Abs(c.Pressure-30.10) > 0.01 {
+		t.Errorf("pressure = %v inHg, want 30.10", c.Pressure)
+	}
+	if c.Icon != "partly_cloudy" {
+		t.Errorf("current icon = %q, want partly_cloudy", c.Icon)
+	}
+
+	if len(f.Hourly) != 24 {
+		t.Fatalf("got %d hours, want 24", len(f.Hourly))
+	}
+	if h := f.Hourly[0]; h.Temp != 52 || h.WindSpeed != 3 || h.PrecipProbability != 5 || h.Icon != "partly_cloudy" {
+		t.Errorf("first hour = %+v", h)
+	}
+
+	// Day and night periods fold into days: the day supplies the high and
+	// the icon, the night the low.
+	days := []struct {
+		high, low float64
+		icon      string
+	}{
+		{69, 52, "mostly_cloudy"},
+		{71, 50, "clear"},
+		{73, 53, "mostly_clear"},
+		{69, 55, "rain"},
+		{64, 50, "tstorm"},
+		{63, 46, "partly_cloudy"},
+		{66, 46, "clear"},
+	}
+	if len(f.Daily) != len(days) {
+		t.Fatalf("got %d days, want %d", len(f.Daily), len(days))
+	}
+	for i, d := range f.Daily {
+		want := days[i]
+		if date := time.Date(2025, 10, 17+i, 0, 0, 0, 0, time.UTC); !d.Date.Equal(date) {
+			t.Errorf("day %d date = %v, want %v", i, d.Date, date)
+		}
+		if d.High != want.high || d.Low != want.low || d.Icon != want.icon {
+			t.Errorf("day %d = %v/%v %q, want %v/%v %q", i, d.High, d.Low, d.Icon, want.high, want.low, want.icon)
+		}
+		// The API has no sun times, so they are computed.
+		if d.Sunrise.IsZero() || !d.Sunset.After(d.Sunrise) {
+			t.Errorf("day %d sunrise, sunset = %v, %v", i, d.Sunrise, d.Sunset)
+		}
+	}
+}
+
+func TestNWSPointsCached(t *testing.T) {
+	srv := newNWSStandIn(t)
+	p := NewNWSProvider(srv.URL+"/", "kindle-weather-test")
+
+	// each device and reload loads its own timezone
+	for i := 0; i < 3; i++ {
+		if _, err := p.Forecast(raleigh(t), UnitsMetric); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := srv.count("/points/35.7800,-78.6400"); n != 1 {
+		t.Errorf("points requested %d times, want 1", n)
+	}
+	if n := srv.count("/gridpoints/RAH/73,57/forecast"); n != 3 {
+		t.Errorf("forecast requested %d times, want 3", n)
+	}
+
+	// an old gridpoint is resolved again rather than kept
+	key := newPointKey(raleigh(t))
+	p.points[key] = nwsPointEntry{point: p.points[key].point, resolved: time.Now().Add(-nwsPointMaxAge - time.Minute)}
+	if _, err := p.Forecast(raleigh(t), UnitsMetric); err != nil {
+		t.Fatal(err)
+	}
+	if n := srv.count("/points/35.7800,-78.6400"); n != 2 {
+		t.Errorf("points requested %d times after they aged, want 2", n)
+	}
+	if len(p.points) != 1 {
+		t.Errorf("%d gridpoints cached, want 1", len(p.points))
+	}
+}
+
+func TestNWSOutsideUS(t *testing.T) {
+	srv := newNWSStandIn(t)
+	p := NewNWSProvider(srv.URL, "kindle-weather-test")
+	london := Location{Lat: 51.5074, Lon: -0.1278, TZ: time.UTC}
+
+	for i := 0; i < 2; i++ {
+		_, err := p.Forecast(london, UnitsMetric)
+		if statusKind(err) != FailureProviderDown {
+			t.Fatalf("err = %v, want a status error", err)
+		}
+		if !strings.Contains(err.Error(), "Invalid Point") {
+			t.Errorf("err = %v, want the API's problem", err)
+		}
+	}
+	// Failed lookups are not cached.
+	if n := srv.count("/points/51.5074,-0.1278"); n != 2 {
+		t.Errorf("points requested %d times, want 2", n)
+	}
+	if len(p.points) != 0 {
+		t.Errorf("%d points cached, want none", len(p.points))
+	}
+}
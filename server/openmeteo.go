@@ -0,0 +1,291 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultOpenMeteoURL           = "https://api.open-meteo.com/v1/forecast"
+	defaultOpenMeteoAirQualityURL = "https://air-quality-api.open-meteo.com/v1/air-quality"
+)
+
+var (
+	openMeteoCurrent = "temperature_2m,apparent_temperature,relative_humidity_2m,weather_code,wind_speed_10m,wind_direction_10m,surface_pressure"
+	openMeteoHourly  = "temperature_2m,precipitation,precipitation_probability,weather_code,wind_speed_10m"
+	openMeteoDaily   = "weather_code,temperature_2m_max,temperature_2m_min,precipitation_sum,sunrise,sunset"
+
+	openMeteoAirQuality = "us_aqi,us_aqi_pm2_5,us_aqi_pm10,us_aqi_ozone,us_aqi_nitrogen_dioxide,us_aqi_carbon_monoxide,us_aqi_sulphur_dioxide,pm2_5,alder_pollen,birch_pollen,olive_pollen,grass_pollen,mugwort_pollen,ragweed_pollen"
+)
+
+// OpenMeteoProvider implements WeatherProvider using the keyless Open-Meteo
+// forecast and air quality APIs.
+type OpenMeteoProvider struct {
+	baseURL       string
+	airQualityURL string
+	client        *http.Client
+}
+
+func NewOpenMeteoProvider(baseURL, airQualityURL string) *OpenMeteoProvider {
+	return &OpenMeteoProvider{
+		baseURL:       baseURL,
+		airQualityURL: airQualityURL,
+		client:        newHTTPClient(),
+	}
+}
+
+func (p *OpenMeteoProvider) Name() string {
+	return "Open-Meteo"
+}
+
+type openMeteoResponse struct {
+	UTCOffsetSeconds int64 `json:"utc_offset_seconds"`
+	Current          struct {
+		Time                int64   `json:"time"`
+		Temperature         float64 `json:"temperature_2m"`
+		ApparentTemperature float64 `json:"apparent_temperature"`
+		Humidity            float64 `json:"relative_humidity_2m"`
+		WeatherCode         int     `json:"weather_code"`
+		WindSpeed           float64 `json:"wind_speed_10m"`
+		WindDirection       float64 `json:"wind_direction_10m"`
+		Pressure            float64 `json:"surface_pressure"`
+	} `json:"current"`
+	Hourly struct {
+		Time                     []int64    `json:"time"`
+		Temperature              []float64  `json:"temperature_2m"`
+		Precipitation            []float64  `json:"precipitation"`
+		PrecipitationProbability []*float64 `json:"precipitation_probability"`
+		WeatherCode              []int      `json:"weather_code"`
+		WindSpeed                []float64  `json:"wind_speed_10m"`
+	} `json:"hourly"`
+	Daily struct {
+		Time             []int64   `json:"time"`
+		WeatherCode      []int     `json:"weather_code"`
+		TemperatureMax   []float64 `json:"temperature_2m_max"`
+		TemperatureMin   []float64 `json:"temperature_2m_min"`
+		PrecipitationSum []float64 `json:"precipitation_sum"`
+		Sunrise          []int64   `json:"sunrise"`
+		Sunset           []int64   `json:"sunset"`
+	} `json:"daily"`
+}
+
+func (p *OpenMeteoProvider) Forecast(loc Location, units string) (*Forecast, error) {
+	q := url.Values{}
+	q.Set("latitude", strconv.FormatFloat(loc.Lat, 'f', -1, 64))
+	q.Set("longitude", strconv.FormatFloat(loc.Lon, 'f', -1, 64))
+	q.Set("current", openMeteoCurrent)
+	q.Set("hourly", openMeteoHourly)
+	q.Set("daily", openMeteoDaily)
+	if units == UnitsImperial {
+		q.Set("temperature_unit", "fahrenheit")
+		q.Set("wind_speed_unit", "mph")
+		q.Set("precipitation_unit", "inch")
+	}
+	q.Set("timeformat", "unixtime")
+	q.Set("timezone", "auto")
+	// today and the most days after it a device can show
+	q.Set("forecast_days", strconv.Itoa(maxForecastDays+1))
+
+	logrus.Info("getting open-meteo forecast data")
+	var resp openMeteoResponse
+	if err := getJSON(p.client, p.baseURL+"?"+q.Encode(), &resp); err != nil {
+		return nil, fmt.Errorf("error getting open-meteo forecast: %w", err)
+	}
+
+	now := time.Now()
+	forecast := &Forecast{
+		Source:    p.Name(),
+		FetchedAt: now,
+		Units:     units,
+		Current: Current{
+			Time:          time.Unix(resp.Current.Time, 0),
+			Temp:          resp.Current.Temperature,
+			FeelsLike:     resp.Current.ApparentTemperature,
+			Humidity:      resp.Current.Humidity,
+			WindSpeed:     resp.Current.WindSpeed,
+			WindDirection: resp.Current.WindDirection,
+			Pressure:      resp.Current.Pressure,
+			Icon:          wmoIcon(resp.Current.WeatherCode),
+			MoonPhase:     moonPhaseAt(now),
+		},
+	}
+
+	// Pressure is always reported in hPa.
+	if units == UnitsImperial {
+		forecast.Current.Pressure = hPaToInHg(forecast.Current.Pressure)
+	}
+
+	h := resp.Hourly
+	for i, ts := range h.Time {
+		hour := Hour{Time: time.Unix(ts, 0)}
+		if i < len(h.Temperature) {
+			hour.Temp = h.Temperature[i]
+		}
+		if i < len(h.Precipitation) {
+			hour.Precipitation = h.Precipitation[i]
+		}
+		if i < len(h.PrecipitationProbability) && h.PrecipitationProbability[i] != nil {
+			hour.PrecipProbability = *h.PrecipitationProbability[i]
+		}
+		if i < len(h.WeatherCode) {
+			hour.Icon = wmoIcon(h.WeatherCode[i])
+		}
+		if i < len(h.WindSpeed) {
+			hour.WindSpeed = h.WindSpeed[i]
+		}
+		forecast.Hourly = append(forecast.Hourly, hour)
+	}
+
+	d := resp.Daily
+	if len(d.TemperatureMax) < len(d.Time) || len(d.TemperatureMin) < len(d.Time) || len(d.WeatherCode) < len(d.Time) {
+		return nil, fmt.Errorf("open-meteo daily forecast is incomplete")
+	}
+	for i, ts := range d.Time {
+		day := Day{
+			Date:      time.Unix(ts+resp.UTCOffsetSeconds, 0).UTC(),
+			High:      d.TemperatureMax[i],
+			Low:       d.TemperatureMin[i],
+			Icon:      wmoIcon(d.WeatherCode[i]),
+			MoonPhase: moonPhaseAt(time.Unix(ts, 0)),
+		}
+		if i < len(d.PrecipitationSum) {
+			day.Precipitation = d.PrecipitationSum[i]
+		}
+		if i < len(d.Sunrise) {
+			day.Sunrise = time.Unix(d.Sunrise[i], 0)
+		}
+		if i < len(d.Sunset) {
+			day.Sunset = time.Unix(d.Sunset[i], 0)
+		}
+		forecast.Daily = append(forecast.Daily, day)
+	}
+	if len(forecast.Daily) > 0 {
+		forecast.Current.Sunrise = forecast.Daily[0].Sunrise
+		forecast.Current.Sunset = forecast.Daily[0].Sunset
+	}
+
+	// The forecast is complete without air quality, so a failure here is
+	// not a reason to try the next provider.
+	if err := p.airQuality(loc, forecast); err != nil {
+		logrus.Warnf("error getting open-meteo air quality: %v", err)
+	}
+	return forecast, nil
+}
+
+type openMeteoAirQualityResponse struct {
+	Current struct {
+		AQI           *float64 `json:"us_aqi"`
+		PM25AQI       *float64 `json:"us_aqi_pm2_5"`
+		PM10AQI       *float64 `json:"us_aqi_pm10"`
+		O3AQI         *float64 `json:"us_aqi_ozone"`
+		NO2AQI        *float64 `json:"us_aqi_nitrogen_dioxide"`
+		COAQI         *float64 `json:"us_aqi_carbon_monoxide"`
+		SO2AQI        *float64 `json:"us_aqi_sulphur_dioxide"`
+		PM25          float64  `json:"pm2_5"`
+		AlderPollen   *float64 `json:"alder_pollen"`
+		BirchPollen   *float64 `json:"birch_pollen"`
+		OlivePollen   *float64 `json:"olive_pollen"`
+		GrassPollen   *float64 `json:"grass_pollen"`
+		MugwortPollen *float64 `json:"mugwort_pollen"`
+		RagweedPollen *float64 `json:"ragweed_pollen"`
+	} `json:"current"`
+}
+
+// airQuality fills in the air quality and pollen of forecast. Open-Meteo
+// only forecasts pollen for Europe; elsewhere Pollen stays nil.
+func (p *OpenMeteoProvider) airQuality(loc Location, forecast *Forecast) error {
+	q := url.Values{}
+	q.Set("latitude", strconv.FormatFloat(loc.Lat, 'f', -1, 64))
+	q.Set("longitude", strconv.FormatFloat(loc.Lon, 'f', -1, 64))
+	q.Set("current", openMeteoAirQuality)
+
+	logrus.Info("getting open-meteo air quality data")
+	var resp openMeteoAirQualityResponse
+	if err := getJSON(p.client, p.airQualityURL+"?"+q.Encode(), &resp); err != nil {
+		return err
+	}
+
+	c := resp.Current
+	if c.AQI != nil {
+		aq := &AirQuality{AQI: int(math.Round(*c.AQI)), PM25: c.PM25}
+		highest := -1.0
+		for i, aqi := range []*float64{c.PM25AQI, c.PM10AQI, c.O3AQI, c.NO2AQI, c.COAQI, c.SO2AQI} {
+			if aqi != nil && *aqi > highest {
+				highest, aq.PrimaryPollutant = *aqi, pollutants[i]
+			}
+		}
+		forecast.AirQuality = aq
+	}
+
+	// The highest count of the pollen types in each group sets its level.
+	group := func(counts ...*float64) (float64, bool) {
+		highest, ok := 0.0, false
+		for _, c := range counts {
+			if c != nil {
+				highest, ok = math.Max(highest, *c), true
+			}
+		}
+		return highest, ok
+	}
+	tree, treeOK := group(c.AlderPollen, c.BirchPollen, c.OlivePollen)
+	grass, grassOK := group(c.GrassPollen)
+	weed, weedOK := group(c.MugwortPollen, c.RagweedPollen)
+	if treeOK || grassOK || weedOK {
+		forecast.Pollen = &Pollen{
+			Tree:  pollenLevel(tree, treePollenLevels),
+			Grass: pollenLevel(grass, grassPollenLevels),
+			Weed:  pollenLevel(weed, weedPollenLevels),
+		}
+	}
+	return nil
+}
+
+// wmoIcon maps a WMO 4677 weather interpretation code, as used by
+// Open-Meteo, onto an icon id defined by the layout templates.
+func wmoIcon(code int) string {
+	switch code {
+	case 0:
+		return "clear"
+	case 1:
+		return "mostly_clear"
+	case 2:
+		return "partly_cloudy"
+	case 3:
+		return "cloudy"
+	case 45:
+		return "fog_light"
+	case 48:
+		return "fog"
+	case 51, 53, 55:
+		return "drizzle"
+	case 56, 57:
+		return "freezing_drizzle"
+	case 61, 80:
+		return "rain_light"
+	case 63, 81:
+		return "rain"
+	case 65, 82:
+		return "rain_heavy"
+	case 66:
+		return "freezing_rain_light"
+	case 67:
+		return "freezing_rain"
+	case 71, 85:
+		return "snow_light"
+	case 73:
+		return "snow"
+	case 75, 86:
+		return "snow_heavy"
+	case 77:
+		return "flurries"
+	case 95, 96, 99:
+		return "tstorm"
+	}
+	return "cloudy"
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// newOpenMeteoStandIn serves the recorded Open-Meteo forecast for Raleigh,
+// NC and records the query of the last forecast request.
+func newOpenMeteoStandIn(t *testing.T) (*httptest.Server, *url.Values) {
+	t.Helper()
+	forecast, err := ioutil.ReadFile("testdata/openmeteo/forecast.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var query url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/forecast":
+			query = r.URL.Query()
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(forecast)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &query
+}
+
+func newTestOpenMeteo(srv *httptest.Server) *OpenMeteoProvider {
+	return NewOpenMeteoProvider(srv.URL+"/v1/forecast", srv.URL+"/v1/air-quality")
+}
+
+func raleigh(t *testing.T) Location {
+	t.Helper()
+	tz, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Location{Lat: 35.78, Lon: -78.64, TZ: tz}
+}
+
+func TestOpenMeteoForecast(t *testing.T) {
+	srv, query := newOpenMeteoStandIn(t)
+	loc := raleigh(t)
+
+	f, err := newTestOpenMeteo(srv).Forecast(loc, UnitsImperial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.validate(raleigh(t)); err != nil {
+		t.Fatalf("forecast is incomplete: %v", err)
+	}
+
+	for key, want := range map[string]string{
+		"latitude":           "35.78",
+		"longitude":          "-78.64",
+		"temperature_unit":   "fahrenheit",
+		"wind_speed_unit":    "mph",
+		"precipitation_unit": "inch",
+		"timeformat":         "unixtime",
+		"timezone":           "auto",
+		"forecast_days":      "8",
+	} {
+		if got := query.Get(key); got != want {
+			t.Errorf("query %s = %q, want %q", key, got, want)
+		}
+	}
+
+	if f.Source != "Open-Meteo" || f.Units != UnitsImperial {
+		t.Errorf("source, units = %q, %q", f.Source, f.Units)
+	}
+	c := f.Current
+	if want := time.Date(2025, 10, 17, 14, 0, 0, 0, loc.TZ); !c.Time.Equal(want) {
+		t.Errorf("current time = %v, want %v", c.Time, want)
+	}
+	if c.Temp != 68.4 || c.FeelsLike != 66.9 || c.Humidity != 52 || c.WindSpeed != 7.3 || c.WindDirection != 225 {
+		t.Errorf("current = %+v", c)
+	}
+	if c.Icon != "partly_cloudy" {
+		t.Errorf("current icon = %q, want partly_cloudy", c.Icon)
+	}
+	// 1004.1 hPa
+	if math.Abs(c.Pressure-29.65) > 0.01 {
+		t.Errorf("pressure = %v inHg, want 29.65", c.Pressure)
+	}
+	if got := c.Sunrise.In(loc.TZ).Format("2006-01-02 15:04"); got != "2025-10-17 07:23" {
+		t.Errorf("sunrise = %s, want 2025-10-17 07:23", got)
+	}
+	if got := c.Sunset.In(loc.TZ).Format("2006-01-02 15:04"); got != "2025-10-17 18:37" {
+		t.Errorf("sunset = %s, want 2025-10-17 18:37", got)
+	}
+
+	if len(f.Hourly) != 192 {
+		t.Fatalf("got %d hours, want 192", len(f.Hourly))
+	}
+	if h := f.Hourly[0]; !h.Time.Equal(time.Date(2025, 10, 17, 0, 0, 0, 0, loc.TZ)) || h.Temp != 55.2 || h.Icon != "clear" {
+		t.Errorf("first hour = %+v", h)
+	}
+	if h := f.Hourly[18]; h.Precipitation != 0.05 || h.PrecipProbability != 51 || h.Icon != "rain" {
+		t.Errorf("hour 18 = %+v", h)
+	}
+
+	icons := []string{"rain", "cloudy", "clear", "mostly_clear", "rain_light", "tstorm", "partly_cloudy", "mostly_clear"}
+	if len(f.Daily) != len(icons) {
+		t.Fatalf("got %d days, want %d", len(f.Daily), len(icons))
+	}
+	for i, d := range f.Daily {
+		// Days are local calendar days as midnight UTC.
+		if want := time.Date(2025, 10, 17+i, 0, 0, 0, 0, time.UTC); !d.Date.Equal(want) {
+			t.Errorf("day %d date = %v, want %v", i, d.Date, want)
+		}
+		if d.Icon != icons[i] {
+			t.Errorf("day %d icon = %q, want %q", i, d.Icon, icons[i])
+		}
+		if local := d.Sunrise.In(loc.TZ); local.Day() != 17+i || local.Hour() != 7 {
+			t.Errorf("day %d sunrise = %v", i, local)
+		}
+		if local := d.Sunset.In(loc.TZ); local.Day() != 17+i || local.Hour() != 18 {
+			t.Errorf("day %d sunset = %v", i, local)
+		}
+	}
+	if d := f.Daily[5]; d.High != 62.8 || d.Low != 46.2 || d.Precipitation != 0.58 {
+		t.Errorf("day 5 = %+v", d)
+	}
+}
+
+func TestOpenMeteoMetric(t *testing.T) {
+	srv, query := newOpenMeteoStandIn(t)
+
+	f, err := newTestOpenMeteo(srv).Forecast(raleigh(t), UnitsMetric)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Open-Meteo's defaults are metric.
+	for _, key := range []string{"temperature_unit", "wind_speed_unit", "precipitation_unit"} {
+		if query.Has(key) {
+			t.Errorf("metric request sets %s=%s", key, query.Get(key))
+		}
+	}
+	if f.Units != UnitsMetric || f.Current.Pressure != 1004.1 {
+		t.Errorf("units, pressure = %q, %v, want metric, 1004.1 hPa", f.Units, f.Current.Pressure)
+	}
+}
+
+func TestOpenMeteoError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":true,"reason":"Latitude must be in range of -90 to 90°."}`, http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	_, err := NewOpenMeteoProvider(srv.URL, srv.URL).Forecast(raleigh(t), UnitsMetric)
+	if statusKind(err) != FailureProviderDown {
+		t.Errorf("err = %v, want a status error", err)
+	}
+}
+
+// TestOpenMeteoAirQualityURL checks that the air quality API is read from
+// the configured URL.
+func TestOpenMeteoAirQualityURL(t *testing.T) {
+	srv, _ := newOpenMeteoStandIn(t)
+	aq := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/mirror/air-quality" || r.URL.Query().Get("current") == "" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"current":{"us_aqi":57,"us_aqi_pm2_5":57,"us_aqi_ozone":31,"pm2_5":14.2,"grass_pollen":30,"birch_pollen":null}}`))
+	}))
+	t.Cleanup(aq.Close)
+
+	p, err := newProvider(ProviderConfig{Name: "openmeteo", URL: srv.URL + "/v1/forecast", AirQualityURL: aq.URL + "/mirror/air-quality"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := p.Forecast(raleigh(t), UnitsImperial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (AirQuality{AQI: 57, PrimaryPollutant: "pm25", PM25: 14.2}); f.AirQuality == nil || *f.AirQuality != want {
+		t.Errorf("air quality = %+v, want %+v", f.AirQuality, want)
+	}
+	if f.Pollen == nil || f.Pollen.Grass == 0 || f.Pollen.Tree != 0 {
+		t.Errorf("pollen = %+v, want grass only", f.Pollen)
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// renderMaxAge is how old a cached forecast may be for an on-demand render,
+// long enough for devices on slow schedules. Older forecasts are drawn with
+// the staleness banner like device images.
+const renderMaxAge = 24 * time.Hour
+
+// On-demand renders are rasterized while the client waits, so they are
+// limited to a burst of renderBurst and then one every renderInterval.
+const (
+	renderBurst    = 5
+	renderInterval = 2 * time.Second
+)
+
+// renderLimiter is a token bucket for on-demand renders. The zero value is
+// full.
+type renderLimiter struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// wait takes a token and returns 0, or returns how long until the next
+// token without taking one.
+func (l *renderLimiter) wait(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.last.IsZero() {
+		l.tokens = renderBurst
+	} else {
+		l.tokens = math.Min(renderBurst, l.tokens+float64(now.Sub(l.last))/float64(renderInterval))
+	}
+	l.last = now
+	if l.tokens < 1 {
+		return time.Duration((1 - l.tokens) * float64(renderInterval))
+	}
+	l.tokens--
+	return 0
+}
+
+// ServeRender renders an image on request at /render, starting from the
+// device named by the device parameter, or the defaults, and overriding its
+// settings from the query:
+//
+//	lat, lon, tz, units, locale, profile, rotation, layout
+//
+// format=svg returns the SVG instead of the PNG. Like device images,
+// renders carry an ETag and Last-Modified for conditional requests. The
+// endpoint is unauthenticated, so it never calls the providers: only places
+// a device was generated for within renderMaxAge can be rendered, with the
+// cached forecast, and alerts are not fetched. Renders are rate limited.
+func (s *Server) ServeRender(w http.ResponseWriter, r *http.Request) {
+	if wait := s.renders.wait(time.Now()); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		http.Error(w, "too many renders, try again shortly", http.StatusTooManyRequests)
+		return
+	}
+	s.mu.Lock()
+	cfg, provider, rasterizer, layouts := s.cfg, s.provider, s.rasterizer, s.layouts
+	s.mu.Unlock()
+	if cfg == nil {
+		http.Error(w, "no valid configuration is running", http.StatusServiceUnavailable)
+		return
+	}
+
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = "png"
+	}
+	if format != "png" && format != "svg" {
+		http.Error(w, fmt.Sprintf("format: unknown format %q, must be png or svg", format), http.StatusBadRequest)
+		return
+	}
+	dc, ok := renderBase(cfg, q.Get("device"))
+	if !ok {
+		http.Error(w, fmt.Sprintf("device: unknown device %q", q.Get("device")), http.StatusNotFound)
+		return
+	}
+	if err := applyQuery(&dc, q, layouts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	device, err := newDevice("render", dc, "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	forecast, ok := provider.Lookup(device.Location, device.Units.Base())
+	if !ok {
+		http.Error(w, fmt.Sprintf("no forecast for %v,%v in %s units; renders use the forecasts fetched for the configured devices",
+			device.Location.Lat, device.Location.Lon, device.Units.Base()), http.StatusNotFound)
+		return
+	}
+	var svg bytes.Buffer
+	if err := layouts[device.Layout].Execute(&svg, newTemplateData(forecast, nil, device, now)); err != nil {
+		logrus.Errorf("failed to render %s: %v", r.URL, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if format == "svg" {
+		serveContent(w, r, "render.svg", forecast.Updated(), svg.Bytes())
+		return
+	}
+	png, err := encodeImage(rasterizer, device, svg.Bytes())
+	if err != nil {
+		logrus.Errorf("failed to render %s: %v", r.URL, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	serveContent(w, r, "render.png", forecast.Updated(), png)
+}
+
+// renderBase returns the settings of the configured device id, or the
+// defaults when id is empty.
+func renderBase(cfg *Config, id string) (DeviceConfig, bool) {
+	if id == "" || (len(cfg.Devices) == 0 && id == "default") {
+		return cfg.Defaults, true
+	}
+	for _, d := range cfg.Devices {
+		if d.ID == id {
+			return cfg.device(d), true
+		}
+	}
+	return DeviceConfig{}, false
+}
+
+// queryParams maps device keys to the /render parameters that set them, so
+// problems are reported with the parameter's name.
+var queryParams = map[string]string{
+	"location.latitude":  "lat",
+	"location.longitude": "lon",
+	"location.timezone":  "tz",
+}
+
+// applyQuery overrides the device keys set in the query and validates the
+// result like a configured device.
+func applyQuery(d *DeviceConfig, q url.Values, layouts map[string]*Layout) error {
+	errs := &ConfigError{Source: "query"}
+	queryFloat(q, "lat", &d.Location.Latitude, errs)
+	queryFloat(q, "lon", &d.Location.Longitude, errs)
+	queryString(q, "tz", &d.Location.Timezone)
+	queryString(q, "units", &d.Units)
+	queryString(q, "locale", &d.Locale)
+	queryString(q, "profile", &d.Profile)
+	if v := q.Get("rotation"); v != "" {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err != nil {
+			errs.addf("rotation: %q is not a whole number", v)
+		} else {
+			d.Rotation = &n
+		}
+	}
+	queryString(q, "layout", &d.Layout)
+
+	names := make([]string, 0, len(layouts))
+	for name := range layouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	invalid := &ConfigError{}
+	d.validate("", names, invalid)
+	for _, problem := range invalid.Problems {
+		problem = strings.TrimPrefix(problem, ".")
+		if i := strings.Index(problem, ":"); i > 0 {
+			if param, ok := queryParams[problem[:i]]; ok {
+				problem = param + problem[i:]
+			}
+		}
+		errs.Problems = append(errs.Problems, problem)
+	}
+	if len(errs.Problems) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func queryString(q url.Values, key string, dst *string) {
+	if v := q.Get(key); v != "" {
+		*dst = v
+	}
+}
+
+func queryFloat(q url.Values, key string, dst **float64, errs *ConfigError) {
+	v := q.Get(key)
+	if v == "" {
+		return
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil {
+		errs.addf("%s: %q is not a number", key, v)
+		return
+	}
+	*dst = &f
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestRenderCachedOnly checks that renders draw the forecasts fetched for
+// the devices and never call the providers for other places.
+func TestRenderCachedOnly(t *testing.T) {
+	s := newTestServer(t, testConfig("default"))
+	srv, _ := newOpenMeteoStandIn(t)
+	forecast, err := newTestOpenMeteo(srv).Forecast(raleigh(t), UnitsImperial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := &stubProvider{forecast: forecast}
+	s.provider = NewForecastCache(stub, renderMaxAge)
+	// the device's run
+	device := s.generators["a"].next.Load().(*generatorSettings).device
+	if _, err := s.provider.Forecast(device.Location, device.Units.Base()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		query string
+		code  int
+	}{
+		{"device=a", http.StatusOK},
+		{"device=a&layout=hourly&format=svg", http.StatusOK},
+		{"device=a&units=metric", http.StatusNotFound},
+		{"device=a&lat=48.85&lon=2.35", http.StatusNotFound},
+	} {
+		w := httptest.NewRecorder()
+		s.ServeRender(w, httptest.NewRequest("GET", "/render?"+tt.query, nil))
+		if w.Code != tt.code {
+			t.Errorf("%s: status %d, want %d: %s", tt.query, w.Code, tt.code, w.Body)
+		}
+	}
+	if stub.calls != 1 {
+		t.Errorf("provider called %d times, want only for the device's run", stub.calls)
+	}
+}
+
+func TestRenderLimiter(t *testing.T) {
+	var l renderLimiter
+	now := time.Now()
+	for i := 0; i < renderBurst; i++ {
+		if wait := l.wait(now); wait != 0 {
+			t.Fatalf("render %d of a burst waits %v", i+1, wait)
+		}
+	}
+	if wait := l.wait(now); wait != renderInterval {
+		t.Errorf("render after a burst waits %v, want %v", wait, renderInterval)
+	}
+	now = now.Add(renderInterval / 2)
+	if wait := l.wait(now); wait != renderInterval/2 {
+		t.Errorf("render waits %v, want %v", wait, renderInterval/2)
+	}
+	now = now.Add(renderInterval / 2)
+	if wait := l.wait(now); wait != 0 {
+		t.Errorf("render after an interval waits %v", wait)
+	}
+	if wait := l.wait(now); wait == 0 {
+		t.Error("second render in an interval was allowed")
+	}
+}
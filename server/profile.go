@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"sort"
+	"strings"
+)
+
+// DeviceProfile describes a Kindle panel. Width and Height are the native
+// portrait resolution; Rotation is the clockwise rotation, in degrees,
+// applied to the rendered layout so a device held in landscape can show a
+// landscape image.
+type DeviceProfile struct {
+	Name       string
+	Width      int
+	Height     int
+	Rotation   int
+	GrayLevels int
+}
+
+var deviceProfiles = map[string]DeviceProfile{
+	"kindle3":     {Name: "kindle3", Width: 600, Height: 800, GrayLevels: 16},
+	"kindle4":     {Name: "kindle4", Width: 600, Height: 800, GrayLevels: 16},
+	"touch":       {Name: "touch", Width: 600, Height: 800, GrayLevels: 16},
+	"paperwhite":  {Name: "paperwhite", Width: 758, Height: 1024, GrayLevels: 16},
+	"paperwhite3": {Name: "paperwhite3", Width: 1072, Height: 1448, GrayLevels: 16},
+	"voyage":      {Name: "voyage", Width: 1072, Height: 1448, GrayLevels: 16},
+	"oasis":       {Name: "oasis", Width: 1264, Height: 1680, GrayLevels: 16},
+}
+
+// lookupProfile returns the named profile with rotation applied. A negative
+// rotation keeps the profile's default.
+func lookupProfile(name string, rotation int) (DeviceProfile, error) {
+	p, ok := deviceProfiles[strings.ToLower(name)]
+	if !ok {
+		names := make([]string, 0, len(deviceProfiles))
+		for n := range deviceProfiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return DeviceProfile{}, fmt.Errorf("unknown device profile %q, must be one of %s", name, strings.Join(names, ", "))
+	}
+	if rotation >= 0 {
+		p.Rotation = rotation
+	}
+	switch p.Rotation {
+	case 0, 90, 180, 270:
+	default:
+		return DeviceProfile{}, fmt.Errorf("rotation must be 0, 90, 180 or 270, got %d", p.Rotation)
+	}
+	return p, nil
+}
+
+// Landscape reports whether the layout is rendered sideways.
+func (p DeviceProfile) Landscape() bool {
+	return p.Rotation == 90 || p.Rotation == 270
+}
+
+// Canvas returns the size of the layout before rotation.
+func (p DeviceProfile) Canvas() (int, int) {
+	if p.Landscape() {
+		return p.Height, p.Width
+	}
+	return p.Width, p.Height
+}
+
+// Scale returns the factor that fits a layout of the given size into the
+// canvas. Layouts learn the canvas's shape from TemplateData; one drawn in
+// the other orientation is shrunk to fit and centred by Fit.
+func (p DeviceProfile) Scale(width, height float64) float64 {
+	cw, ch := p.Canvas()
+	return math.Min(float64(cw)/width, float64(ch)/height)
+}
+
+// Fit centres img on a white canvas and rotates it so the result matches
+// the panel's native resolution pixel for pixel.
+func (p DeviceProfile) Fit(img image.Image) image.Image {
+	cw, ch := p.Canvas()
+	canvas := image.NewGray(image.Rect(0, 0, cw, ch))
+	draw.Draw(canvas, canvas.Bounds(), image.White, image.Point{}, draw.Src)
+
+	b := img.Bounds()
+	offset := image.Pt((cw-b.Dx())/2, (ch-b.Dy())/2)
+	draw.Draw(canvas, b.Sub(b.Min).Add(offset), img, b.Min, draw.Src)
+	return rotateGray(canvas, p.Rotation)
+}
+
+// rotateGray rotates img clockwise by a multiple of 90 degrees.
+func rotateGray(img *image.Gray, degrees int) *image.Gray {
+	if degrees == 0 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	var out *image.Gray
+	if degrees == 180 {
+		out = image.NewGray(image.Rect(0, 0, w, h))
+	} else {
+		out = image.NewGray(image.Rect(0, 0, h, w))
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := img.Pix[y*img.Stride+x]
+			switch degrees {
+			case 90:
+				out.Pix[x*out.Stride+(h-1-y)] = v
+			case 180:
+				out.Pix[(h-1-y)*out.Stride+(w-1-x)] = v
+			case 270:
+				out.Pix[(w-1-x)*out.Stride+y] = v
+			}
+		}
+	}
+	return out
+}
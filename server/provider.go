@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WeatherProvider fetches weather data for a location and returns it in the
+// provider-neutral Forecast model used to fill the image template. units is
+// the base unit system, UnitsImperial or UnitsMetric, the values should be
+// in; providers request it from their API where they can.
+type WeatherProvider interface {
+	Name() string
+	Forecast(loc Location, units string) (*Forecast, error)
+}
+
+// Location is a point on the globe in decimal degrees and the timezone
+// used to present times there.
+type Location struct {
+	Lat float64
+	Lon float64
+	TZ  *time.Location
+}
+
+// pointKey identifies a location in caches of per-location API data by its
+// coordinates rounded to four decimals, about 11 m. A Location is no key:
+// every time.LoadLocation returns a new TZ, so reloads and devices sharing
+// a place would miss.
+type pointKey struct {
+	lat, lon float64
+}
+
+func newPointKey(loc Location) pointKey {
+	return pointKey{lat: math.Round(loc.Lat*1e4) / 1e4, lon: math.Round(loc.Lon*1e4) / 1e4}
+}
+
+// Forecast is the provider-neutral weather model. Icon and MoonPhase values
+// are ids of symbols defined in the layout templates (e.g. "rain_heavy",
+// "waxing_crescent"). Values are in the base unit system named by Units: °F,
+// mph, inHg and inches for UnitsImperial or °C, km/h, hPa and mm for
+// UnitsMetric; probabilities and humidity are percentages.
+type Forecast struct {
+	Source    string
+	FetchedAt time.Time
+	Units     string
+	Current   Current
+	Hourly    []Hour
+	Daily     []Day
+	// AirQuality and Pollen are nil when the provider does not report them
+	// for the location.
+	AirQuality *AirQuality
+	Pollen     *Pollen
+}
+
+// Current holds the observed (or nowcast) conditions.
+type Current struct {
+	Time          time.Time
+	Temp          float64
+	FeelsLike     float64
+	Humidity      float64
+	WindSpeed     float64
+	WindDirection float64
+	Pressure      float64
+	Icon          string
+	// Sunrise and Sunset are zero while the sun does not rise or set.
+	Sunrise   time.Time
+	Sunset    time.Time
+	MoonPhase string
+}
+
+// Hour is a single hourly forecast step.
+type Hour struct {
+	Time              time.Time
+	Temp              float64
+	Precipitation     float64
+	PrecipProbability float64
+	WindSpeed         float64
+	Icon              string
+}
+
+// Day is a single daily forecast entry. The first entry is today. Date is the
+// local calendar day expressed as midnight UTC.
+type Day struct {
+	Date          time.Time
+	High          float64
+	Low           float64
+	Precipitation float64
+	Icon          string
+	Sunrise       time.Time
+	Sunset        time.Time
+	MoonPhase     string
+}
+
+// AirQuality is the current air quality on the US EPA index.
+type AirQuality struct {
+	// AQI is the EPA air quality index, 0 to 500.
+	AQI int
+	// PrimaryPollutant is the pollutant that sets the index: "pm25", "pm10",
+	// "o3", "no2", "co" or "so2", or empty when unknown.
+	PrimaryPollutant string
+	// PM25 is the concentration of fine particulate matter in µg/m³.
+	PM25 float64
+}
+
+// Pollen holds the current pollen levels on a scale of 0 (none) to 5 (very
+// high).
+type Pollen struct {
+	Tree  int
+	Grass int
+	Weed  int
+}
+
+// validate reports whether the forecast for loc has enough data to fill the
+// image. The sunrise and sunset may be missing during polar day or night.
+func (f *Forecast) validate(loc Location) error {
+	switch {
+	case f.Current.Icon == "":
+		return fmt.Errorf("forecast has no current conditions")
+	case (f.Current.Sunrise.IsZero() || f.Current.Sunset.IsZero()) && !polarDay(loc, f.Updated()):
+		return fmt.Errorf("forecast has no sunrise or sunset")
+	case len(f.Daily) == 0:
+		return fmt.Errorf("forecast has no days")
+	}
+	return nil
+}
+
+// Updated returns when the provider last updated the forecast's data: the
+// time of the current conditions, or when it was fetched if that is unknown
+// or earlier.
+func (f *Forecast) Updated() time.Time {
+	if f.Current.Time.IsZero() || f.Current.Time.After(f.FetchedAt) {
+		return f.FetchedAt
+	}
+	return f.Current.Time
+}
+
+// newProviderChain builds a ProviderChain that tries the configured
+// providers in order.
+func newProviderChain(configs []ProviderConfig) (*ProviderChain, error) {
+	var providers []WeatherProvider
+	for _, c := range configs {
+		p, err := newProvider(c)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, p)
+	}
+	return NewProviderChain(providers...), nil
+}
+
+// defaultUserAgent identifies the server to APIs that require a User-Agent.
+const defaultUserAgent = "kindle-weather-display (https://github.com/maskarb/kindle-weather-display)"
+
+// newProvider builds the provider registered under c.Name, using the
+// provider's default URL and User-Agent when c leaves them empty.
+func newProvider(c ProviderConfig) (WeatherProvider, error) {
+	orDefault := func(v, def string) string {
+		if v == "" {
+			return def
+		}
+		return v
+	}
+	userAgent := orDefault(c.UserAgent, defaultUserAgent)
+
+	switch canonicalProvider(c.Name) {
+	case "climacell":
+		return NewClimaCellProvider(c.APIKey), nil
+	case "openmeteo":
+		return NewOpenMeteoProvider(orDefault(c.URL, defaultOpenMeteoURL), orDefault(c.AirQualityURL, defaultOpenMeteoAirQualityURL)), nil
+	case "tomorrow":
+		return NewTomorrowProvider(orDefault(c.URL, defaultTomorrowURL), c.APIKey), nil
+	case "nws":
+		return NewNWSProvider(orDefault(c.URL, defaultNWSURL), userAgent), nil
+	case "metno":
+		return NewMetNoProvider(orDefault(c.URL, defaultMetNoURL), userAgent), nil
+	}
+	return nil, fmt.Errorf("unknown weather provider %q", c.Name)
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: time.Minute}
+}
+
+// getJSON issues a GET request for url and decodes the JSON body into v.
+func getJSON(c *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	return doJSON(c, req, v)
+}
+
+// StatusError is a non-2xx response from a weather API.
+type StatusError struct {
+	StatusCode int
+	msg        string
+}
+
+func (e *StatusError) Error() string {
+	return e.msg
+}
+
+// doJSON sends req and decodes the JSON body into v. Non-2xx responses are
+// returned as a StatusError including the start of the body.
+func doJSON(c *http.Client, req *http.Request, v interface{}) error {
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return &StatusError{
+			StatusCode: resp.StatusCode,
+			msg:        fmt.Sprintf("%s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(body))),
+		}
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func celsiusToFahrenheit(c float64) float64 {
+	return c*9/5 + 32
+}
+
+func fahrenheitToCelsius(f float64) float64 {
+	return (f - 32) * 5 / 9
+}
+
+func kphToMph(kph float64) float64 {
+	return kph / 1.609344
+}
+
+func mphToKph(mph float64) float64 {
+	return mph * 1.609344
+}
+
+func mmToInches(mm float64) float64 {
+	return mm / 25.4
+}
+
+func hPaToInHg(hPa float64) float64 {
+	return hPa * 0.0295299830714
+}
+
+func inHgToHPa(inHg float64) float64 {
+	return inHg / 0.0295299830714
+}
@@ -0,0 +1,880 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+	"github.com/srwiley/rasterx"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// fontPaths are the locations searched for DejaVu Sans, the font the
+// templates are designed for. Alpine's ttf-dejavu package installs it in
+// the first two.
+var fontPaths = []string{
+	"/usr/share/fonts/dejavu/DejaVuSans.ttf",
+	"/usr/share/fonts/ttf-dejavu/DejaVuSans.ttf",
+	"/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf",
+}
+
+// Rasterizer converts an SVG document into an image, scaling it by scale.
+type Rasterizer interface {
+	Rasterize(svg []byte, scale float64) (image.Image, error)
+}
+
+// newRasterizer returns the rasterizer registered under name: "builtin"
+// renders in-process, "rsvg" shells out to rsvg-convert.
+func newRasterizer(name, fontPath string) (Rasterizer, error) {
+	switch strings.ToLower(name) {
+	case "", "builtin":
+		return NewSVGRenderer(fontPath)
+	case "rsvg":
+		return rsvgRasterizer{}, nil
+	}
+	return nil, fmt.Errorf("unknown renderer %q", name)
+}
+
+// rsvgRasterizer uses the external rsvg-convert tool.
+type rsvgRasterizer struct{}
+
+func (rsvgRasterizer) Rasterize(svg []byte, scale float64) (image.Image, error) {
+	logrus.Info("converting svg to png")
+	var out bytes.Buffer
+	cmd := exec.Command("rsvg-convert", "-b", "white", "-f", "png", "-z", strconv.FormatFloat(scale, 'f', -1, 64))
+	cmd.Stdin = bytes.NewReader(svg)
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("error convert svg to png: %v", err)
+	}
+	img, err := png.Decode(&out)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding rsvg-convert output: %v", err)
+	}
+	return img, nil
+}
+
+// SVGRenderer rasterises the subset of SVG used by our templates: paths and
+// basic shapes with fill and stroke, nested groups with transforms, symbols
+// referenced through <use>, and single line text. It is safe for concurrent
+// use; renders are serialised because font faces are not.
+type SVGRenderer struct {
+	mu    sync.Mutex
+	font  *opentype.Font
+	faces map[float64]font.Face
+}
+
+// NewSVGRenderer loads the font at fontPath, or the first DejaVu Sans found
+// in fontPaths when it is empty, falling back to the embedded Go font.
+func NewSVGRenderer(fontPath string) (*SVGRenderer, error) {
+	paths := fontPaths
+	if fontPath != "" {
+		paths = []string{fontPath}
+	}
+
+	data := goregular.TTF
+	for _, p := range paths {
+		b, err := ioutil.ReadFile(p)
+		if err == nil {
+			data = b
+			break
+		}
+		if fontPath != "" {
+			return nil, fmt.Errorf("error reading font: %v", err)
+		}
+	}
+	if bytes.Equal(data, goregular.TTF) {
+		logrus.Info("DejaVu Sans not found, using the Go font")
+	}
+
+	f, err := opentype.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing font: %v", err)
+	}
+	return &SVGRenderer{font: f, faces: make(map[float64]font.Face)}, nil
+}
+
+func (r *SVGRenderer) Rasterize(svg []byte, scale float64) (image.Image, error) {
+	logrus.Info("rendering svg")
+	img, err := r.RenderScaled(svg, scale)
+	if err != nil {
+		return nil, fmt.Errorf("error rendering svg: %v", err)
+	}
+	return img, nil
+}
+
+// svgNode is a generic SVG element.
+type svgNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []*svgNode `xml:",any"`
+	Text     string     `xml:",chardata"`
+}
+
+func (n *svgNode) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// svgStyle holds the inherited presentation attributes.
+type svgStyle struct {
+	m           rasterx.Matrix2D
+	fill        string
+	fillRule    string
+	stroke      string
+	strokeWidth float64
+	fontSize    float64
+	textAnchor  string
+}
+
+// with returns a copy of s updated with the attributes and style
+// declarations of n.
+func (s svgStyle) with(n *svgNode) svgStyle {
+	props := make(map[string]string)
+	for _, a := range n.Attrs {
+		props[a.Name.Local] = a.Value
+	}
+	for _, decl := range strings.Split(n.attr("style"), ";") {
+		kv := strings.SplitN(decl, ":", 2)
+		if len(kv) == 2 {
+			props[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+	}
+
+	if v, ok := props["fill"]; ok {
+		s.fill = v
+	}
+	if v, ok := props["fill-rule"]; ok {
+		s.fillRule = v
+	}
+	if v, ok := props["stroke"]; ok {
+		s.stroke = v
+	}
+	if v, ok := props["stroke-width"]; ok {
+		s.strokeWidth = parseLength(v)
+	}
+	if v, ok := props["font-size"]; ok {
+		s.fontSize = parseLength(v)
+	}
+	if v, ok := props["text-anchor"]; ok {
+		s.textAnchor = v
+	}
+	if v, ok := props["transform"]; ok {
+		s.m = s.m.Mult(parseTransform(v))
+	}
+	return s
+}
+
+// svgSize returns the width and height of the root svg element.
+func svgSize(svg []byte) (float64, float64, error) {
+	d := xml.NewDecoder(bytes.NewReader(svg))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return 0, 0, fmt.Errorf("error parsing svg: %v", err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			n := svgNode{XMLName: start.Name, Attrs: start.Attr}
+			w, h := parseLength(n.attr("width")), parseLength(n.attr("height"))
+			if w <= 0 || h <= 0 {
+				return 0, 0, fmt.Errorf("svg has no width or height")
+			}
+			return w, h, nil
+		}
+	}
+}
+
+type renderContext struct {
+	r       *SVGRenderer
+	img     *image.RGBA
+	ids     map[string]*svgNode
+	scanner *rasterx.ScannerGV
+	filler  *rasterx.Filler
+	stroker *rasterx.Stroker
+}
+
+// Render rasterises svg onto a white background at the size given by the
+// root element's width and height.
+func (r *SVGRenderer) Render(svg []byte) (*image.RGBA, error) {
+	return r.RenderScaled(svg, 1)
+}
+
+// RenderScaled rasterises svg like Render, scaling the document by scale.
+func (r *SVGRenderer) RenderScaled(svg []byte, scale float64) (*image.RGBA, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var root svgNode
+	if err := xml.Unmarshal(svg, &root); err != nil {
+		return nil, fmt.Errorf("error parsing svg: %v", err)
+	}
+	if root.XMLName.Local != "svg" {
+		return nil, fmt.Errorf("root element is %q, not svg", root.XMLName.Local)
+	}
+
+	w := int(math.Ceil(parseLength(root.attr("width")) * scale))
+	h := int(math.Ceil(parseLength(root.attr("height")) * scale))
+	if w <= 0 || h <= 0 {
+		return nil, fmt.Errorf("svg has no width or height")
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	scanner := rasterx.NewScannerGV(w, h, img, img.Bounds())
+	ctx := &renderContext{
+		r:       r,
+		img:     img,
+		ids:     make(map[string]*svgNode),
+		scanner: scanner,
+		filler:  rasterx.NewFiller(w, h, scanner),
+		stroker: rasterx.NewStroker(w, h, scanner),
+	}
+	ctx.index(&root)
+
+	style := svgStyle{
+		m:          rasterx.Identity.Scale(scale, scale),
+		fill:       "black",
+		stroke:     "none",
+		fontSize:   16,
+		textAnchor: "start",
+	}
+	for _, c := range root.Children {
+		ctx.draw(c, style, 0)
+	}
+	return img, nil
+}
+
+func (ctx *renderContext) index(n *svgNode) {
+	if id := n.attr("id"); id != "" {
+		ctx.ids[id] = n
+	}
+	for _, c := range n.Children {
+		ctx.index(c)
+	}
+}
+
+// maxUseDepth guards against <use> elements that reference themselves.
+const maxUseDepth = 16
+
+func (ctx *renderContext) draw(n *svgNode, parent svgStyle, depth int) {
+	switch n.XMLName.Local {
+	case "defs", "title", "desc", "metadata", "style":
+		return
+	}
+	s := parent.with(n)
+
+	switch n.XMLName.Local {
+	case "g", "svg", "symbol":
+		for _, c := range n.Children {
+			ctx.draw(c, s, depth)
+		}
+	case "use":
+		ref := ctx.ids[strings.TrimPrefix(n.attr("href"), "#")]
+		if ref == nil || depth >= maxUseDepth {
+			return
+		}
+		s.m = s.m.Translate(parseLength(n.attr("x")), parseLength(n.attr("y")))
+		ctx.draw(ref, s, depth+1)
+	case "path":
+		p, err := parsePathData(n.attr("d"))
+		if err != nil {
+			logrus.Debugf("skipping path: %v", err)
+			return
+		}
+		ctx.paint(p, s)
+	case "rect":
+		ctx.paint(rectPath(n), s)
+	case "circle":
+		r := parseLength(n.attr("r"))
+		ctx.paint(ellipsePath(parseLength(n.attr("cx")), parseLength(n.attr("cy")), r, r), s)
+	case "ellipse":
+		ctx.paint(ellipsePath(parseLength(n.attr("cx")), parseLength(n.attr("cy")), parseLength(n.attr("rx")), parseLength(n.attr("ry"))), s)
+	case "line":
+		var p pathData
+		p.moveTo(parseLength(n.attr("x1")), parseLength(n.attr("y1")))
+		p.lineTo(parseLength(n.attr("x2")), parseLength(n.attr("y2")))
+		ctx.paint(p, s)
+	case "polygon", "polyline":
+		p := pointsPath(n.attr("points"))
+		if n.XMLName.Local == "polygon" {
+			p.close()
+		}
+		ctx.paint(p, s)
+	case "text":
+		ctx.text(n, s)
+	}
+}
+
+// paint fills and strokes p in device space.
+func (ctx *renderContext) paint(p pathData, s svgStyle) {
+	if len(p) == 0 {
+		return
+	}
+	if c, ok := parseColor(s.fill); ok {
+		ctx.filler.Clear()
+		ctx.filler.SetWinding(s.fillRule != "evenodd")
+		ctx.filler.SetColor(c)
+		p.addTo(ctx.filler, s.m)
+		ctx.filler.Draw()
+	}
+	if c, ok := parseColor(s.stroke); ok && s.strokeWidth > 0 {
+		scale := math.Sqrt(math.Abs(s.m.A*s.m.D - s.m.B*s.m.C))
+		ctx.stroker.Clear()
+		ctx.stroker.SetWinding(true)
+		ctx.stroker.SetColor(c)
+		ctx.stroker.SetStroke(fixed.Int26_6(s.strokeWidth*scale*64), 4<<6, rasterx.ButtCap, nil, rasterx.FlatGap, rasterx.Miter)
+		p.addTo(ctx.stroker, s.m)
+		ctx.stroker.Draw()
+	}
+}
+
+// text draws the text content of n. Rotation and skew are ignored; the
+// font is scaled by the transform's scale factor.
+func (ctx *renderContext) text(n *svgNode, s svgStyle) {
+	str := strings.TrimSpace(collectText(n))
+	c, ok := parseColor(s.fill)
+	if str == "" || !ok {
+		return
+	}
+
+	scale := math.Sqrt(math.Abs(s.m.A*s.m.D - s.m.B*s.m.C))
+	face, err := ctx.r.face(s.fontSize * scale)
+	if err != nil {
+		logrus.Errorf("error loading font face: %v", err)
+		return
+	}
+	x, y := s.m.Transform(parseLength(n.attr("x")), parseLength(n.attr("y")))
+
+	d := font.Drawer{Dst: ctx.img, Src: image.NewUniform(c), Face: face}
+	switch s.textAnchor {
+	case "middle":
+		x -= float64(d.MeasureString(str)) / 64 / 2
+	case "end":
+		x -= float64(d.MeasureString(str)) / 64
+	}
+	d.Dot = fixed.Point26_6{X: fixed.Int26_6(x * 64), Y: fixed.Int26_6(y * 64)}
+	d.DrawString(str)
+}
+
+func collectText(n *svgNode) string {
+	s := n.Text
+	for _, c := range n.Children {
+		s += collectText(c)
+	}
+	return s
+}
+
+func (r *SVGRenderer) face(size float64) (font.Face, error) {
+	size = math.Round(size*4) / 4
+	if f, ok := r.faces[size]; ok {
+		return f, nil
+	}
+	f, err := opentype.NewFace(r.font, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingNone})
+	if err != nil {
+		return nil, err
+	}
+	r.faces[size] = f
+	return f, nil
+}
+
+// pathData is a path in user space. Arcs are converted to cubic curves when
+// parsed, so only move, line, cubic and close operations remain.
+type pathData []pathOp
+
+type pathOp struct {
+	kind byte // 'M', 'L', 'C' or 'Z'
+	pts  []float64
+}
+
+func (p *pathData) moveTo(x, y float64) { *p = append(*p, pathOp{'M', []float64{x, y}}) }
+func (p *pathData) lineTo(x, y float64) { *p = append(*p, pathOp{'L', []float64{x, y}}) }
+func (p *pathData) close()              { *p = append(*p, pathOp{'Z', nil}) }
+
+func (p *pathData) cubicTo(x1, y1, x2, y2, x, y float64) {
+	*p = append(*p, pathOp{'C', []float64{x1, y1, x2, y2, x, y}})
+}
+
+// addTo transforms the path by m and feeds it to a rasterx adder.
+func (p pathData) addTo(a rasterx.Adder, m rasterx.Matrix2D) {
+	pt := func(x, y float64) fixed.Point26_6 {
+		return rasterx.ToFixedP(m.Transform(x, y))
+	}
+	open := false
+	for _, op := range p {
+		switch op.kind {
+		case 'M':
+			if open {
+				a.Stop(false)
+			}
+			a.Start(pt(op.pts[0], op.pts[1]))
+			open = true
+		case 'L':
+			a.Line(pt(op.pts[0], op.pts[1]))
+		case 'C':
+			a.CubeBezier(pt(op.pts[0], op.pts[1]), pt(op.pts[2], op.pts[3]), pt(op.pts[4], op.pts[5]))
+		case 'Z':
+			if open {
+				a.Stop(true)
+				open = false
+			}
+		}
+	}
+	if open {
+		a.Stop(false)
+	}
+}
+
+func rectPath(n *svgNode) pathData {
+	x, y := parseLength(n.attr("x")), parseLength(n.attr("y"))
+	w, h := parseLength(n.attr("width")), parseLength(n.attr("height"))
+	var p pathData
+	if w <= 0 || h <= 0 {
+		return p
+	}
+	p.moveTo(x, y)
+	p.lineTo(x+w, y)
+	p.lineTo(x+w, y+h)
+	p.lineTo(x, y+h)
+	p.close()
+	return p
+}
+
+func ellipsePath(cx, cy, rx, ry float64) pathData {
+	var p pathData
+	if rx <= 0 || ry <= 0 {
+		return p
+	}
+	// four cubic quarter arcs
+	const k = 0.5522847498
+	p.moveTo(cx+rx, cy)
+	p.cubicTo(cx+rx, cy+k*ry, cx+k*rx, cy+ry, cx, cy+ry)
+	p.cubicTo(cx-k*rx, cy+ry, cx-rx, cy+k*ry, cx-rx, cy)
+	p.cubicTo(cx-rx, cy-k*ry, cx-k*rx, cy-ry, cx, cy-ry)
+	p.cubicTo(cx+k*rx, cy-ry, cx+rx, cy-k*ry, cx+rx, cy)
+	p.close()
+	return p
+}
+
+func pointsPath(points string) pathData {
+	var p pathData
+	sc := pathScanner{s: points}
+	for i := 0; sc.more(); i++ {
+		x, err := sc.number()
+		if err != nil {
+			break
+		}
+		y, err := sc.number()
+		if err != nil {
+			break
+		}
+		if i == 0 {
+			p.moveTo(x, y)
+		} else {
+			p.lineTo(x, y)
+		}
+	}
+	return p
+}
+
+// parsePathData parses the d attribute of a path element.
+func parsePathData(d string) (pathData, error) {
+	var p pathData
+	sc := pathScanner{s: d}
+	var cmd byte
+	var x, y, startX, startY float64
+	// reflected control point of the previous curve, for S and T
+	var ctrlX, ctrlY float64
+	var prev byte
+
+	for sc.more() {
+		if c, ok := sc.command(); ok {
+			cmd = c
+		} else if cmd == 0 {
+			return nil, fmt.Errorf("path data does not start with a command")
+		} else if cmd == 'Z' || cmd == 'z' {
+			// closepath takes no parameters, so it never repeats
+			return nil, fmt.Errorf("path data has numbers after %c without a command", cmd)
+		}
+		rel := cmd >= 'a'
+		ox, oy := 0.0, 0.0
+		if rel {
+			ox, oy = x, y
+		}
+
+		switch unicode.ToUpper(rune(cmd)) {
+		case 'Z':
+			p.close()
+			x, y = startX, startY
+			prev = 'Z'
+			continue
+		case 'M':
+			v, err := sc.numbers(2)
+			if err != nil {
+				return nil, err
+			}
+			x, y = ox+v[0], oy+v[1]
+			startX, startY = x, y
+			p.moveTo(x, y)
+			// further coordinate pairs are implicit line commands
+			if rel {
+				cmd = 'l'
+			} else {
+				cmd = 'L'
+			}
+			prev = 'M'
+			continue
+		case 'L':
+			v, err := sc.numbers(2)
+			if err != nil {
+				return nil, err
+			}
+			x, y = ox+v[0], oy+v[1]
+			p.lineTo(x, y)
+		case 'H':
+			v, err := sc.numbers(1)
+			if err != nil {
+				return nil, err
+			}
+			x = ox + v[0]
+			p.lineTo(x, y)
+		case 'V':
+			v, err := sc.numbers(1)
+			if err != nil {
+				return nil, err
+			}
+			y = oy + v[0]
+			p.lineTo(x, y)
+		case 'C':
+			v, err := sc.numbers(6)
+			if err != nil {
+				return nil, err
+			}
+			p.cubicTo(ox+v[0], oy+v[1], ox+v[2], oy+v[3], ox+v[4], oy+v[5])
+			ctrlX, ctrlY = ox+v[2], oy+v[3]
+			x, y = ox+v[4], oy+v[5]
+			prev = 'C'
+			continue
+		case 'S':
+			v, err := sc.numbers(4)
+			if err != nil {
+				return nil, err
+			}
+			x1, y1 := x, y
+			if prev == 'C' {
+				x1, y1 = 2*x-ctrlX, 2*y-ctrlY
+			}
+			p.cubicTo(x1, y1, ox+v[0], oy+v[1], ox+v[2], oy+v[3])
+			ctrlX, ctrlY = ox+v[0], oy+v[1]
+			x, y = ox+v[2], oy+v[3]
+			prev = 'C'
+			continue
+		case 'Q', 'T':
+			var qx, qy float64
+			var v []float64
+			var err error
+			if unicode.ToUpper(rune(cmd)) == 'Q' {
+				if v, err = sc.numbers(4); err != nil {
+					return nil, err
+				}
+				qx, qy = ox+v[0], oy+v[1]
+				v = v[2:]
+			} else {
+				if v, err = sc.numbers(2); err != nil {
+					return nil, err
+				}
+				qx, qy = x, y
+				if prev == 'Q' {
+					qx, qy = 2*x-ctrlX, 2*y-ctrlY
+				}
+			}
+			ex, ey := ox+v[0], oy+v[1]
+			// raise the quadratic curve to a cubic one
+			p.cubicTo(x+2.0/3*(qx-x), y+2.0/3*(qy-y), ex+2.0/3*(qx-ex), ey+2.0/3*(qy-ey), ex, ey)
+			ctrlX, ctrlY = qx, qy
+			x, y = ex, ey
+			prev = 'Q'
+			continue
+		case 'A':
+			v, err := sc.numbers(3)
+			if err != nil {
+				return nil, err
+			}
+			large, err := sc.flag()
+			if err != nil {
+				return nil, err
+			}
+			sweep, err := sc.flag()
+			if err != nil {
+				return nil, err
+			}
+			end, err := sc.numbers(2)
+			if err != nil {
+				return nil, err
+			}
+			ex, ey := ox+end[0], oy+end[1]
+			p.arcTo(x, y, v[0], v[1], v[2], large, sweep, ex, ey)
+			x, y = ex, ey
+		default:
+			return nil, fmt.Errorf("unsupported path command %q", cmd)
+		}
+		prev = 'L'
+	}
+	return p, nil
+}
+
+// arcTo appends an elliptical arc from (x1, y1) to (x2, y2) as cubic curves,
+// following the endpoint to center conversion in SVG 1.1 appendix F.6.
+func (p *pathData) arcTo(x1, y1, rx, ry, angle float64, large, sweep bool, x2, y2 float64) {
+	if x1 == x2 && y1 == y2 {
+		return
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		p.lineTo(x2, y2)
+		return
+	}
+
+	phi := angle * math.Pi / 180
+	sinPhi, cosPhi := math.Sin(phi), math.Cos(phi)
+	dx, dy := (x1-x2)/2, (y1-y2)/2
+	x1p := cosPhi*dx + sinPhi*dy
+	y1p := -sinPhi*dx + cosPhi*dy
+
+	// scale up radii that are too small to reach the end point
+	if l := x1p*x1p/(rx*rx) + y1p*y1p/(ry*ry); l > 1 {
+		rx *= math.Sqrt(l)
+		ry *= math.Sqrt(l)
+	}
+
+	num := rx*rx*ry*ry - rx*rx*y1p*y1p - ry*ry*x1p*x1p
+	den := rx*rx*y1p*y1p + ry*ry*x1p*x1p
+	coef := 0.0
+	if num > 0 && den > 0 {
+		coef = math.Sqrt(num / den)
+	}
+	if large == sweep {
+		coef = -coef
+	}
+	cxp := coef * rx * y1p / ry
+	cyp := -coef * ry * x1p / rx
+	cx := cosPhi*cxp - sinPhi*cyp + (x1+x2)/2
+	cy := sinPhi*cxp + cosPhi*cyp + (y1+y2)/2
+
+	vecAngle := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+	theta1 := vecAngle(1, 0, (x1p-cxp)/rx, (y1p-cyp)/ry)
+	delta := vecAngle((x1p-cxp)/rx, (y1p-cyp)/ry, (-x1p-cxp)/rx, (-y1p-cyp)/ry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	// split into segments of at most 90 degrees
+	segs := int(math.Ceil(math.Abs(delta) / (math.Pi / 2)))
+	d := delta / float64(segs)
+	k := 4.0 / 3 * math.Tan(d/4)
+	point := func(t float64) (float64, float64, float64, float64) {
+		cosT, sinT := math.Cos(t), math.Sin(t)
+		px := cx + rx*cosT*cosPhi - ry*sinT*sinPhi
+		py := cy + rx*cosT*sinPhi + ry*sinT*cosPhi
+		// derivative
+		dx := -rx*sinT*cosPhi - ry*cosT*sinPhi
+		dy := -rx*sinT*sinPhi + ry*cosT*cosPhi
+		return px, py, dx, dy
+	}
+	t := theta1
+	sx, sy, sdx, sdy := point(t)
+	for i := 0; i < segs; i++ {
+		ex, ey, edx, edy := point(t + d)
+		if i == segs-1 {
+			ex, ey = x2, y2
+		}
+		p.cubicTo(sx+k*sdx, sy+k*sdy, ex-k*edx, ey-k*edy, ex, ey)
+		t += d
+		sx, sy, sdx, sdy = ex, ey, edx, edy
+	}
+}
+
+// pathScanner tokenises path data and other number lists.
+type pathScanner struct {
+	s string
+	i int
+}
+
+func (sc *pathScanner) skip() {
+	for sc.i < len(sc.s) {
+		switch sc.s[sc.i] {
+		case ' ', '\t', '\n', '\r', ',':
+			sc.i++
+		default:
+			return
+		}
+	}
+}
+
+func (sc *pathScanner) more() bool {
+	sc.skip()
+	return sc.i < len(sc.s)
+}
+
+func (sc *pathScanner) command() (byte, bool) {
+	sc.skip()
+	if sc.i < len(sc.s) && strings.IndexByte("MmLlHhVvCcSsQqTtAaZz", sc.s[sc.i]) >= 0 {
+		sc.i++
+		return sc.s[sc.i-1], true
+	}
+	return 0, false
+}
+
+func (sc *pathScanner) number() (float64, error) {
+	sc.skip()
+	start := sc.i
+	if sc.i < len(sc.s) && (sc.s[sc.i] == '-' || sc.s[sc.i] == '+') {
+		sc.i++
+	}
+	dot, exp := false, false
+	for sc.i < len(sc.s) {
+		c := sc.s[sc.i]
+		switch {
+		case c >= '0' && c <= '9':
+		case c == '.' && !dot && !exp:
+			dot = true
+		case (c == 'e' || c == 'E') && !exp && sc.i > start:
+			exp = true
+			if sc.i+1 < len(sc.s) && (sc.s[sc.i+1] == '-' || sc.s[sc.i+1] == '+') {
+				sc.i++
+			}
+		default:
+			goto done
+		}
+		sc.i++
+	}
+done:
+	v, err := strconv.ParseFloat(sc.s[start:sc.i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number at offset %d in %q", start, sc.s)
+	}
+	return v, nil
+}
+
+func (sc *pathScanner) numbers(n int) ([]float64, error) {
+	v := make([]float64, n)
+	for i := range v {
+		var err error
+		if v[i], err = sc.number(); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// flag reads an arc flag, which may be written without a separator.
+func (sc *pathScanner) flag() (bool, error) {
+	sc.skip()
+	if sc.i < len(sc.s) && (sc.s[sc.i] == '0' || sc.s[sc.i] == '1') {
+		sc.i++
+		return sc.s[sc.i-1] == '1', nil
+	}
+	return false, fmt.Errorf("invalid arc flag at offset %d in %q", sc.i, sc.s)
+}
+
+// parseTransform parses a transform attribute such as
+// "translate(32 26) scale(14)" or "rotate(45, 12, 12)".
+func parseTransform(s string) rasterx.Matrix2D {
+	m := rasterx.Identity
+	for {
+		open := strings.IndexByte(s, '(')
+		end := strings.IndexByte(s, ')')
+		if open < 0 || end < open {
+			return m
+		}
+		name := strings.TrimSpace(strings.Trim(s[:open], " ,"))
+		var args []float64
+		sc := pathScanner{s: s[open+1 : end]}
+		for sc.more() {
+			v, err := sc.number()
+			if err != nil {
+				break
+			}
+			args = append(args, v)
+		}
+		arg := func(i int, def float64) float64 {
+			if i < len(args) {
+				return args[i]
+			}
+			return def
+		}
+
+		switch name {
+		case "translate":
+			m = m.Translate(arg(0, 0), arg(1, 0))
+		case "scale":
+			m = m.Scale(arg(0, 1), arg(1, arg(0, 1)))
+		case "rotate":
+			cx, cy := arg(1, 0), arg(2, 0)
+			m = m.Translate(cx, cy).Rotate(arg(0, 0)*math.Pi/180).Translate(-cx, -cy)
+		case "matrix":
+			if len(args) == 6 {
+				m = m.Mult(rasterx.Matrix2D{A: args[0], B: args[1], C: args[2], D: args[3], E: args[4], F: args[5]})
+			}
+		case "skewX":
+			m = m.SkewX(arg(0, 0) * math.Pi / 180)
+		case "skewY":
+			m = m.SkewY(arg(0, 0) * math.Pi / 180)
+		}
+		s = s[end+1:]
+	}
+}
+
+// parseLength parses a length, ignoring px units.
+func parseLength(s string) float64 {
+	v, _ := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "px"), 64)
+	return v
+}
+
+var namedColors = map[string]color.Color{
+	"black": color.Black,
+	"white": color.White,
+	"gray":  color.Gray{Y: 0x80},
+	"grey":  color.Gray{Y: 0x80},
+}
+
+// parseColor parses a named or hex color. ok is false for "none".
+func parseColor(s string) (color.Color, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if c, ok := namedColors[s]; ok {
+		return c, true
+	}
+	if strings.HasPrefix(s, "#") {
+		hex := s[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if v, err := strconv.ParseUint(hex, 16, 32); err == nil && len(hex) == 6 {
+			return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, true
+		}
+	}
+	return nil, false
+}
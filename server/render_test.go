@@ -0,0 +1,52 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePathData(t *testing.T) {
+	for _, tt := range []struct {
+		d     string
+		kinds string
+	}{
+		{"M0 0 L1 1 z", "MLZ"},
+		{"M0 0 1 1 2 2Z M5 5 h1 v1 z", "MLLZMLLZ"},
+		{"m1,1 l1-1zl2 2", "MLZL"},
+		{"M0 0 C1 1 2 2 3 3 S4 4 5 5", "MCC"},
+	} {
+		p, err := parsePathData(tt.d)
+		if err != nil {
+			t.Errorf("parsePathData(%q): %v", tt.d, err)
+			continue
+		}
+		var kinds []byte
+		for _, op := range p {
+			kinds = append(kinds, op.kind)
+		}
+		if string(kinds) != tt.kinds {
+			t.Errorf("parsePathData(%q) = %s, want %s", tt.d, kinds, tt.kinds)
+		}
+	}
+
+	// After z the path continues from the start of the closed subpath.
+	p, err := parsePathData("M2 3 l4 0 z l1 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p[len(p)-1].pts; !reflect.DeepEqual(got, []float64{3, 4}) {
+		t.Errorf("line after z ends at %v, want [3 4]", got)
+	}
+
+	for _, d := range []string{
+		"0 0 L1 1",
+		"M0 0 L1 1 z 5 5",
+		"M0 0 L1 1 Z5",
+		"M0 0 L1",
+		"M0 0 L1 x",
+	} {
+		if _, err := parsePathData(d); err == nil {
+			t.Errorf("parsePathData(%q) succeeded, want an error", d)
+		}
+	}
+}
@@ -0,0 +1,397 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/robfig/cron"
+	"github.com/sirupsen/logrus"
+)
+
+// reloadDelay coalesces the burst of events editors produce when saving.
+const reloadDelay = 500 * time.Millisecond
+
+// Server owns the running configuration: the provider chain and its
+// forecast cache, the rasterizer, one FileGenerator per device and the cron that drives them.
+// Reload swaps in a new configuration while the HTTP server keeps serving
+// the images generated so far.
+type Server struct {
+	configPath     string
+	configRequired bool
+	outDir         string
+
+	// applyMu serializes apply, which builds the new state without
+	// holding mu.
+	applyMu sync.Mutex
+
+	mu         sync.Mutex
+	cfg        *Config
+	provider   *ForecastCache
+	alerts     AlertSources
+	rasterizer Rasterizer
+	layouts    map[string]*Layout
+	generators map[string]*FileGenerator
+	// outputs maps the output directory of each device to its generator.
+	outputs map[string]*FileGenerator
+	cron    *cron.Cron
+	// configFailure is served for every image while the server has no
+	// valid configuration to run.
+	configFailure *Failure
+
+	renders renderLimiter
+}
+
+// NewServer loads the configuration, generates every device's image once
+// and starts the cron. When the configuration is invalid the server still
+// starts, serving an error image that explains why until a reload fixes it.
+func NewServer(configPath string, configRequired bool, outDir string) (*Server, error) {
+	s := &Server{
+		configPath:     configPath,
+		configRequired: configRequired,
+		outDir:         outDir,
+		generators:     make(map[string]*FileGenerator),
+		outputs:        make(map[string]*FileGenerator),
+	}
+	cfg, err := LoadConfig(configPath, configRequired)
+	var changed []*FileGenerator
+	if err == nil {
+		changed, err = s.apply(cfg)
+	}
+	if err != nil {
+		logrus.Errorf("serving an error image until the configuration is fixed: %v", err)
+		if err := s.setConfigFailure(err); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+	for _, f := range changed {
+		f.Run()
+	}
+	return s, nil
+}
+
+// setConfigFailure draws the error image for an invalid configuration with
+// the default device settings, as the configured ones cannot be trusted.
+func (s *Server) setConfigFailure(cfgErr error) error {
+	device, err := newDevice("default", defaultConfig().Defaults, s.outDir)
+	if err != nil {
+		return err
+	}
+	r, err := newRasterizer("builtin", "")
+	if err != nil {
+		return err
+	}
+	failure, err := renderFailure(r, device, FailureConfigInvalid, cfgErr, time.Now(), time.Time{})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.configFailure = failure
+	s.mu.Unlock()
+	return nil
+}
+
+// Reload reads the configuration again and applies it. An invalid
+// configuration is rejected and the running one is kept; without one, the
+// error image is redrawn with the new problems. Devices whose settings
+// changed are regenerated in the background.
+func (s *Server) Reload() error {
+	cfg, err := LoadConfig(s.configPath, s.configRequired)
+	var changed []*FileGenerator
+	if err == nil {
+		changed, err = s.apply(cfg)
+	}
+	if err != nil {
+		s.mu.Lock()
+		running := s.cfg != nil
+		s.mu.Unlock()
+		if !running {
+			if err := s.setConfigFailure(err); err != nil {
+				logrus.Errorf("failed to draw error image: %v", err)
+			}
+		}
+		return err
+	}
+	logrus.Infof("configuration reloaded, regenerating %d device(s)", len(changed))
+	for _, f := range changed {
+		go f.Run()
+	}
+	return nil
+}
+
+// apply swaps in cfg and returns the generators whose output is now out of
+// date. Everything is built and checked before anything is swapped, so an
+// invalid configuration leaves the running one untouched, and s.mu is only
+// held for the swap itself: generators take their new settings on their next
+// run rather than being waited for. The provider chain, alert sources and
+// rasterizer are only rebuilt when their settings change, so provider health
+// and response caches survive a reload.
+func (s *Server) apply(cfg *Config) ([]*FileGenerator, error) {
+	s.applyMu.Lock()
+	defer s.applyMu.Unlock()
+
+	s.mu.Lock()
+	running, provider, alerts, rasterizer, current := s.cfg, s.provider, s.alerts, s.rasterizer, s.generators
+	s.mu.Unlock()
+
+	providerChanged := running == nil || !reflect.DeepEqual(running.Providers, cfg.Providers)
+	if providerChanged {
+		chain, err := newProviderChain(cfg.Providers)
+		if err != nil {
+			return nil, err
+		}
+		provider = NewForecastCache(chain, renderMaxAge)
+	}
+	alertsChanged := running == nil || !reflect.DeepEqual(running.Alerts, cfg.Alerts)
+	if alertsChanged {
+		sources, err := newAlertSources(cfg.Alerts)
+		if err != nil {
+			return nil, err
+		}
+		alerts = sources
+	}
+	rasterizerChanged := running == nil || running.Renderer != cfg.Renderer || running.FontPath != cfg.FontPath
+	if rasterizerChanged {
+		r, err := newRasterizer(cfg.Renderer, cfg.FontPath)
+		if err != nil {
+			return nil, err
+		}
+		rasterizer = r
+	}
+
+	layouts, err := loadLayouts(cfg.TemplatesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := cfg.BuildDevices(s.outDir)
+	if err != nil {
+		return nil, err
+	}
+	settings := make([]*generatorSettings, 0, len(devices))
+	for _, device := range devices {
+		layout, ok := layouts[device.Layout]
+		if !ok {
+			return nil, fmt.Errorf("device %s: unknown layout %q", device.ID, device.Layout)
+		}
+		settings = append(settings, &generatorSettings{
+			provider:   provider,
+			alerts:     alerts,
+			rasterizer: rasterizer,
+			layout:     layout,
+			device:     device,
+		})
+	}
+
+	// Nothing fails from here on.
+	var changed []*FileGenerator
+	generators := make(map[string]*FileGenerator)
+	outputs := make(map[string]*FileGenerator)
+	c := cron.New()
+	for _, next := range settings {
+		device := next.device
+		f, existing := current[device.ID]
+		if !existing {
+			f = &FileGenerator{id: device.ID}
+		}
+		settingsChanged := f.configure(next)
+		if !existing || providerChanged || alertsChanged || rasterizerChanged || settingsChanged {
+			changed = append(changed, f)
+		}
+		generators[device.ID] = f
+		outputs[device.OutDir] = f
+
+		c.Schedule(device.Schedule, f)
+		if len(alerts) > 0 {
+			c.Schedule(device.AlertSchedule, alertJob{f})
+		}
+		logrus.Infof("starting cronjob for %s on schedule: %s", device.ID, device.CronSpec)
+	}
+
+	s.mu.Lock()
+	old := s.cron
+	s.cfg = cfg
+	s.provider = provider
+	s.alerts = alerts
+	s.rasterizer = rasterizer
+	s.layouts = layouts
+	s.generators = generators
+	s.outputs = outputs
+	s.cron = c
+	s.configFailure = nil
+	s.mu.Unlock()
+
+	if old != nil {
+		old.Stop()
+	}
+	c.Start()
+	for id := range current {
+		if _, ok := generators[id]; !ok {
+			logrus.Infof("device %s removed", id)
+			forgetDevice(id)
+		}
+	}
+	return changed, nil
+}
+
+// ServeOutput serves the generated files under /out/. A device's
+// output.png is replaced by an error image, with status 503 and the
+// failure kind in the X-Weather-Error header, while the image cannot be
+// generated and the last one is missing or out of date. Files are served
+// with an ETag and, for a device's output.png and output.svg, the time of
+// the forecast data in each as Last-Modified, so pollers are answered 304
+// while nothing changed.
+func (s *Server) ServeOutput(w http.ResponseWriter, r *http.Request) {
+	name := outputName(r.URL.Path)
+	s.mu.Lock()
+	failure := s.configFailure
+	f := s.outputs[s.outputDir(r.URL.Path)]
+	s.mu.Unlock()
+	base := path.Base(name)
+	if base == "output.png" && failure == nil && f != nil {
+		failure = f.Failure()
+	}
+	if base == "output.png" && failure != nil {
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Weather-Error", failure.Kind)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write(failure.PNG)
+		return
+	}
+
+	data, info, err := readOutput(filepath.Join(s.outDir, filepath.FromSlash(name)))
+	if err != nil {
+		// Directories and missing files are left to the file server.
+		http.StripPrefix("/out", http.FileServer(http.Dir(s.outDir))).ServeHTTP(w, r)
+		return
+	}
+	modified := info.ModTime()
+	if f != nil {
+		if m := f.Modified(base); !m.IsZero() {
+			modified = m
+		}
+	}
+	serveContent(w, r, base, modified, data)
+}
+
+// outputName returns the name of the file requested at urlPath under /out/.
+func outputName(urlPath string) string {
+	return path.Clean("/" + strings.TrimPrefix(urlPath, "/out"))
+}
+
+// outputDir returns the directory holding the file requested at urlPath,
+// the key of its device in s.outputs.
+func (s *Server) outputDir(urlPath string) string {
+	return filepath.Join(s.outDir, filepath.FromSlash(path.Dir(outputName(urlPath))))
+}
+
+// readOutput reads the regular file at path. The contents and file info
+// come from the same open file, as outputs are replaced while being served.
+func readOutput(path string) ([]byte, os.FileInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, nil, fmt.Errorf("%s is not a file", path)
+	}
+	data, err := ioutil.ReadAll(file)
+	return data, info, err
+}
+
+// serveContent serves data with a content hash as ETag and modified as
+// Last-Modified, answering conditional requests with 304. The content type
+// follows from name's extension.
+func serveContent(w http.ResponseWriter, r *http.Request, name string, modified time.Time, data []byte) {
+	w.Header().Set("ETag", contentETag(data))
+	w.Header().Set("Cache-Control", "no-cache")
+	http.ServeContent(w, r, name, modified, bytes.NewReader(data))
+}
+
+// contentETag returns a strong ETag for data.
+func contentETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("%q", hex.EncodeToString(sum[:16]))
+}
+
+// Watch reloads the configuration when the config file changes or the
+// process receives SIGHUP. The directory is watched rather than the file so
+// that editors and orchestrators which replace the file are noticed.
+func (s *Server) Watch() {
+	reload := make(chan struct{}, 1)
+	trigger := func() {
+		select {
+		case reload <- struct{}{}:
+		default:
+		}
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			logrus.Info("received SIGHUP")
+			trigger()
+		}
+	}()
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		logrus.Errorf("cannot watch config file, reload with SIGHUP: %v", err)
+	} else if err := watcher.Add(filepath.Dir(s.configPath)); err != nil {
+		logrus.Errorf("cannot watch config file, reload with SIGHUP: %v", err)
+		watcher.Close()
+	} else {
+		go func() {
+			base := filepath.Base(s.configPath)
+			var timer *time.Timer
+			for {
+				select {
+				case ev, ok := <-watcher.Events:
+					if !ok {
+						return
+					}
+					// Kubernetes swaps mounted ConfigMaps through a ..data symlink.
+					if name := filepath.Base(ev.Name); name != base && name != "..data" {
+						continue
+					}
+					if timer != nil {
+						timer.Stop()
+					}
+					timer = time.AfterFunc(reloadDelay, trigger)
+				case err, ok := <-watcher.Errors:
+					if !ok {
+						return
+					}
+					logrus.Errorf("config watcher: %v", err)
+				}
+			}
+		}()
+	}
+
+	for range reload {
+		if err := s.Reload(); err != nil {
+			logrus.Errorf("keeping the running configuration: %v", err)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T, cfg *Config) *Server {
+	t.Helper()
+	s := &Server{
+		outDir:     t.TempDir(),
+		generators: make(map[string]*FileGenerator),
+		outputs:    make(map[string]*FileGenerator),
+	}
+	if _, err := s.apply(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.cron.Stop()
+	})
+	return s
+}
+
+func testConfig(layouts ...string) *Config {
+	cfg := defaultConfig()
+	cfg.Providers = []ProviderConfig{{Name: "openmeteo"}}
+	for i, layout := range layouts {
+		cfg.Devices = append(cfg.Devices, DeviceConfig{ID: string(rune('a' + i)), Layout: layout})
+	}
+	return cfg
+}
+
+func nextLayout(f *FileGenerator) string {
+	return f.next.Load().(*generatorSettings).layout.Name
+}
+
+// TestApplyDuringRun checks that a reload neither waits for a generation in
+// progress nor holds up requests meanwhile.
+func TestApplyDuringRun(t *testing.T) {
+	s := newTestServer(t, testConfig("default"))
+	f := s.generators["a"]
+	// a run in progress
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.apply(testConfig("hourly"))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("reload waited for the run")
+	}
+	if got := nextLayout(f); got != "hourly" {
+		t.Errorf("next run uses layout %q, want hourly", got)
+	}
+	if id := s.requestDevice("forecast", httptest.NewRequest("GET", "/api/v1/forecast?device=a", nil)); id != "a" {
+		t.Errorf("request for device %q, want a", id)
+	}
+}
+
+// TestApplyInvalid checks that a configuration that fails part way through
+// leaves every generator as it was.
+func TestApplyInvalid(t *testing.T) {
+	s := newTestServer(t, testConfig("default", "default"))
+	a, b := s.generators["a"], s.generators["b"]
+
+	// b's layout is checked after a's settings are built
+	if _, err := s.apply(testConfig("hourly", "missing")); err == nil {
+		t.Fatal("apply succeeded with an unknown layout")
+	}
+	if got := nextLayout(a); got != "default" {
+		t.Errorf("a uses layout %q after a failed reload, want default", got)
+	}
+	if got := nextLayout(b); got != "default" {
+		t.Errorf("b uses layout %q after a failed reload, want default", got)
+	}
+	if s.generators["a"] != a || s.cfg.Devices[0].Layout != "default" {
+		t.Error("the failed configuration was swapped in")
+	}
+}
+
+// newTestOutput returns a server with device a drawing the recorded
+// Open-Meteo forecast through a stub provider, which has not run yet.
+func newTestOutput(t *testing.T) (*Server, *FileGenerator, *stubProvider) {
+	t.Helper()
+	srv, _ := newOpenMeteoStandIn(t)
+	forecast, err := newTestOpenMeteo(srv).Forecast(raleigh(t), UnitsImperial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newTestServer(t, testConfig("default"))
+	f := s.generators["a"]
+	t.Cleanup(func() { forgetDevice(f.id) })
+	provider := &stubProvider{forecast: forecast}
+	settings := *f.next.Load().(*generatorSettings)
+	settings.provider = provider
+	f.configure(&settings)
+	return s, f, provider
+}
+
+func getOutput(s *Server, path string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", path, nil)
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	s.ServeOutput(w, r)
+	return w
+}
+
+// TestServeOutputConditional checks that the outputs are answered 304 while
+// they have not changed, each by its own ETag and Last-Modified.
+func TestServeOutputConditional(t *testing.T) {
+	s, f, provider := newTestOutput(t)
+	f.Run()
+	updated := provider.forecast.Updated().UTC().Format(http.TimeFormat)
+
+	etags := make(map[string]string)
+	for _, name := range []string{"output.png", "output.svg"} {
+		path := "/out/a/" + name
+		w := getOutput(s, path, nil)
+		etag := w.Header().Get("ETag")
+		if w.Code != http.StatusOK || etag == "" || w.Body.Len() == 0 {
+			t.Fatalf("%s: status %d, ETag %q", name, w.Code, etag)
+		}
+		if got := w.Header().Get("Last-Modified"); got != updated {
+			t.Errorf("%s: Last-Modified %s, want the forecast's %s", name, got, updated)
+		}
+		etags[name] = etag
+
+		for _, tt := range []struct {
+			header http.Header
+			want   int
+		}{
+			{http.Header{"If-None-Match": {etag}}, http.StatusNotModified},
+			{http.Header{"If-None-Match": {`"other"`}}, http.StatusOK},
+			{http.Header{"If-Modified-Since": {updated}}, http.StatusNotModified},
+			{http.Header{"If-Modified-Since": {provider.forecast.Updated().Add(-time.Hour).UTC().Format(http.TimeFormat)}}, http.StatusOK},
+			// If-None-Match takes precedence
+			{http.Header{"If-None-Match": {`"other"`}, "If-Modified-Since": {updated}}, http.StatusOK},
+		} {
+			if w := getOutput(s, path, tt.header); w.Code != tt.want {
+				t.Errorf("%s with %v: status %d, want %d", name, tt.header, w.Code, tt.want)
+			}
+		}
+	}
+	if etags["output.png"] == etags["output.svg"] {
+		t.Error("the png and svg have the same ETag")
+	}
+
+	// an identical image keeps its validators
+	f.Run()
+	if w := getOutput(s, "/out/a/output.png", http.Header{"If-None-Match": {etags["output.png"]}}); w.Code != http.StatusNotModified {
+		t.Errorf("status %d after an unchanged run, want 304", w.Code)
+	}
+
+	// each file is tracked on its own
+	later := provider.forecast.Updated().Add(time.Hour)
+	f.mu.Lock()
+	f.outMu.Lock()
+	f.changed("output.svg", []byte("<svg/>"), later, later)
+	f.outMu.Unlock()
+	f.mu.Unlock()
+	if got := getOutput(s, "/out/a/output.svg", nil).Header().Get("Last-Modified"); got != later.UTC().Format(http.TimeFormat) {
+		t.Errorf("svg Last-Modified %s after it changed, want %s", got, later.UTC().Format(http.TimeFormat))
+	}
+	if got := getOutput(s, "/out/a/output.png", nil).Header().Get("Last-Modified"); got != updated {
+		t.Errorf("png Last-Modified %s after only the svg changed, want %s", got, updated)
+	}
+}
+
+// TestServeOutputFailure checks that the error image is served with 503
+// and the failure kind while there is no recent image, and the last image
+// otherwise.
+func TestServeOutputFailure(t *testing.T) {
+	s, f, provider := newTestOutput(t)
+	provider.err = chainError{fmt.Errorf("stub: %w", &StatusError{StatusCode: http.StatusUnauthorized, msg: "invalid key"})}
+	f.Run()
+
+	w := getOutput(s, "/out/a/output.png", nil)
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("X-Weather-Error") != FailureKeyRejected {
+		t.Fatalf("status %d, X-Weather-Error %q; want 503 and %s", w.Code, w.Header().Get("X-Weather-Error"), FailureKeyRejected)
+	}
+	if ct, cc := w.Header().Get("Content-Type"), w.Header().Get("Cache-Control"); ct != "image/png" || cc != "no-cache" {
+		t.Errorf("Content-Type %q, Cache-Control %q", ct, cc)
+	}
+	img, err := png.Decode(w.Body)
+	if err != nil {
+		t.Fatalf("error image: %v", err)
+	}
+	if b, p := img.Bounds(), f.settings.device.Profile; b.Dx() != p.Width || b.Dy() != p.Height {
+		t.Errorf("error image is %dx%d, want the screen's %dx%d", b.Dx(), b.Dy(), p.Width, p.Height)
+	}
+
+	// a recent image is kept through a failure
+	provider.err = nil
+	f.Run()
+	provider.err = chainError{fmt.Errorf("stub: %w", &StatusError{StatusCode: http.StatusTooManyRequests, msg: "slow down"})}
+	f.Run()
+	if w := getOutput(s, "/out/a/output.png", nil); w.Code != http.StatusOK || w.Header().Get("X-Weather-Error") != "" {
+		t.Errorf("status %d, X-Weather-Error %q with a recent image; want it served", w.Code, w.Header().Get("X-Weather-Error"))
+	}
+
+	// and replaced once it is older than stale_after
+	old := time.Now().Add(-f.settings.device.StaleAfter - time.Minute)
+	if err := os.Chtimes(filepath.Join(f.settings.device.OutDir, "output.png"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	f.Run()
+	if w := getOutput(s, "/out/a/output.png", nil); w.Code != http.StatusServiceUnavailable || w.Header().Get("X-Weather-Error") != FailureRateLimited {
+		t.Errorf("status %d, X-Weather-Error %q with an old image; want 503 and %s", w.Code, w.Header().Get("X-Weather-Error"), FailureRateLimited)
+	}
+}
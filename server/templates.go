@@ -0,0 +1,366 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+//go:embed templates/default.svg
+var defaultLayout string
+
+//go:embed templates/hourly.svg
+var hourlyLayout string
+
+// partials holds the templates shared by the built-in layouts, such as the
+// icon symbols and the footer. Every layout is parsed with them.
+//
+//go:embed templates/partials.svg
+var partials string
+
+// builtinLayouts are compiled into the binary. A file of the same name in
+// the templates directory replaces them.
+var builtinLayouts = map[string]string{
+	"default": defaultLayout,
+	"hourly":  hourlyLayout,
+}
+
+// layoutExt is the extension of layout files in the templates directory.
+const layoutExt = ".svg"
+
+var symbolIDRe = regexp.MustCompile(`\bid="([^"]+)"`)
+
+// Layout is a parsed SVG template.
+type Layout struct {
+	Name   string
+	source string
+	tmpl   *template.Template
+	// symbols are the ids defined in the template and the partials it
+	// includes, used by the icon function.
+	symbols map[string]bool
+}
+
+// The range of Device.ForecastDays.
+const (
+	minForecastDays = 3
+	maxForecastDays = 7
+)
+
+// TemplateData is what layouts are executed with.
+type TemplateData struct {
+	Forecast *Forecast
+	Device   *Device
+	Now      time.Time
+	Labels   Labels
+	// Today is the forecast for the current day in the device's timezone,
+	// nil when the provider did not return it.
+	Today *Day
+	// Days are the device's ForecastDays days after today. Days the
+	// provider did not return are nil, so layouts can leave them blank.
+	Days []*Day
+	// Alerts are the weather warnings in effect, most severe first.
+	Alerts []Alert
+	// Age is the time since the forecast was fetched. Stale is set when it
+	// is more than the device's StaleAfter, which happens while every
+	// provider fails and the last forecast is shown again.
+	Age   time.Duration
+	Stale bool
+	// Width and Height are the size in pixels of the canvas the layout is
+	// scaled onto, before rotation. Landscape is set when the device is
+	// rotated so the canvas is wider than tall; layouts that draw a
+	// portrait image either way are scaled down to fit it.
+	Width, Height int
+	Landscape     bool
+}
+
+func newTemplateData(f *Forecast, alerts []Alert, device *Device, now time.Time) *TemplateData {
+	data := &TemplateData{
+		Forecast: f,
+		Alerts:   alerts,
+		Device:   device,
+		Now:      now,
+		Labels:   device.Locale.Labels,
+		Days:     make([]*Day, device.ForecastDays),
+		Age:      now.Sub(f.FetchedAt),
+	}
+	data.Stale = data.Age > device.StaleAfter
+	data.Width, data.Height = device.Profile.Canvas()
+	data.Landscape = device.Profile.Landscape()
+	local := now.In(device.Location.TZ)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	for i := range f.Daily {
+		d := &f.Daily[i]
+		n := int(math.Round(d.Date.Sub(today).Hours() / 24))
+		switch {
+		case n == 0:
+			data.Today = d
+		case n > 0 && n <= len(data.Days):
+			data.Days[n-1] = d
+		}
+	}
+	return data
+}
+
+// loadLayouts parses the built-in layouts and every layout file in dir,
+// which may be empty.
+func loadLayouts(dir string) (map[string]*Layout, error) {
+	sources := make(map[string]string)
+	for name, src := range builtinLayouts {
+		sources[name] = src
+	}
+	if dir != "" {
+		files, err := filepath.Glob(filepath.Join(dir, "*"+layoutExt))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			b, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("error reading template: %v", err)
+			}
+			sources[strings.TrimSuffix(filepath.Base(file), layoutExt)] = string(b)
+		}
+	}
+
+	layouts := make(map[string]*Layout)
+	for name, src := range sources {
+		l, err := parseLayout(name, src)
+		if err != nil {
+			return nil, err
+		}
+		layouts[name] = l
+	}
+	return layouts, nil
+}
+
+// layoutNames lists the layouts available with the templates directory dir
+// without parsing them.
+func layoutNames(dir string) ([]string, error) {
+	var names []string
+	for name := range builtinLayouts {
+		names = append(names, name)
+	}
+	if dir != "" {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, err
+		}
+		files, err := filepath.Glob(filepath.Join(dir, "*"+layoutExt))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			name := strings.TrimSuffix(filepath.Base(file), layoutExt)
+			if _, ok := builtinLayouts[name]; !ok {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// parseLayout parses src after the partials, so a layout can replace a
+// partial by defining a template of the same name. The template is named
+// after the layout's file, which no partial is.
+func parseLayout(name, src string) (*Layout, error) {
+	l := &Layout{Name: name, source: src, symbols: make(map[string]bool)}
+	t, err := template.New(name + layoutExt).Funcs(l.funcs(nil)).Parse(partials)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing partials: %v", err)
+	}
+	if t, err = t.Parse(src); err != nil {
+		return nil, fmt.Errorf("error parsing template: %v", err)
+	}
+	l.tmpl = t
+	l.addSymbols(t, make(map[string]bool))
+	return l, nil
+}
+
+// addSymbols adds the ids defined in t and in the templates it includes.
+func (l *Layout) addSymbols(t *template.Template, seen map[string]bool) {
+	if t == nil || t.Tree == nil || seen[t.Name()] {
+		return
+	}
+	seen[t.Name()] = true
+	for _, m := range symbolIDRe.FindAllStringSubmatch(t.Tree.Root.String(), -1) {
+		l.symbols[m[1]] = true
+	}
+	var walk func(parse.Node)
+	walk = func(n parse.Node) {
+		switch n := n.(type) {
+		case *parse.ListNode:
+			if n != nil {
+				for _, c := range n.Nodes {
+					walk(c)
+				}
+			}
+		case *parse.IfNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			l.addSymbols(t.Lookup(n.Name), seen)
+		}
+	}
+	walk(t.Tree.Root)
+}
+
+// Execute renders the layout for data. The template is parsed once; its
+// functions are bound to the device of each execution.
+func (l *Layout) Execute(w io.Writer, data *TemplateData) error {
+	t, err := l.tmpl.Clone()
+	if err != nil {
+		return err
+	}
+	if err := t.Funcs(l.funcs(data)).Execute(w, data); err != nil {
+		return fmt.Errorf("template execution error: %v", err)
+	}
+	return nil
+}
+
+// funcs returns the template functions for data. Values are converted from
+// the forecast's unit system to the device's units, and times are shown in
+// the device's timezone and locale.
+func (l *Layout) funcs(data *TemplateData) template.FuncMap {
+	var (
+		units  Units
+		locale = locales["en"]
+		tz     = time.UTC
+		loc    = Location{TZ: time.UTC}
+		from   string
+		f      = &Forecast{}
+		now    time.Time
+	)
+	if data != nil {
+		units, locale, tz, loc = data.Device.Units, data.Device.Locale, data.Device.Location.TZ, data.Device.Location
+		from, f, now = data.Forecast.Units, data.Forecast, data.Now
+	}
+
+	return template.FuncMap{
+		// number formats v with the given number of decimals and a decimal
+		// point, as SVG attributes need.
+		"number": func(v float64, decimals int) string {
+			return strconv.FormatFloat(v, 'f', decimals, 64)
+		},
+		"temp":         func(v float64) string { return units.FormatTemperature(v, from) },
+		"wind":         func(v float64) string { return units.FormatWind(v, from) },
+		"pressure":     func(v float64) string { return locale.FormatNumber(units.FormatPressure(v, from)) },
+		"precip":       func(v float64) string { return locale.FormatNumber(units.FormatPrecipitation(v, from)) },
+		"tempUnit":     units.TemperatureLabel,
+		"windUnit":     units.WindSpeedLabel,
+		"pressureUnit": func() string { return units.Pressure },
+		"precipUnit":   func() string { return units.Precipitation },
+		// time and date format instants in the device's timezone. time
+		// shows a dash for a missing instant, such as the sunrise during
+		// polar night.
+		"time": func(t time.Time) string {
+			if t.IsZero() {
+				return "–"
+			}
+			return locale.FormatTime(t.In(tz))
+		},
+		"date": func(t time.Time) string { return locale.FormatDate(t.In(tz)) },
+		// when is the time of an instant, after its weekday unless it is
+		// on the same day as the image.
+		"when": func(t time.Time) string {
+			t = t.In(tz)
+			if y, m, d := t.Date(); now.IsZero() || sameDay(now.In(tz), y, m, d) {
+				return locale.FormatTime(t)
+			}
+			return locale.Weekday(t.Weekday()) + " " + locale.FormatTime(t)
+		},
+		// age says how old data of the given age is.
+		"age": locale.FormatAge,
+		// format formats an instant in the device's timezone with a Go layout.
+		"format": func(layout string, t time.Time) string { return t.In(tz).Format(layout) },
+		// weekday names a calendar day, such as Day.Date.
+		"weekday": func(t time.Time) string { return locale.Weekday(t.Weekday()) },
+		"moon":    locale.MoonPhase,
+		// aqiCategory names the category of an air quality index,
+		// pollutant names an AirQuality.PrimaryPollutant and pollenLevel a
+		// pollen level.
+		"aqiCategory": locale.AQICategory,
+		"pollutant":   pollutantName,
+		"pollenLevel": locale.PollenLevel,
+		// icon returns the id of the symbol for an icon id, picking the day
+		// or night variant when given the time it is for.
+		"icon": func(id string, at ...time.Time) string {
+			if len(at) > 0 {
+				id = getWeatherIcon(id, f.dayOrNight(loc, at[0]))
+			}
+			if l.symbols[id] {
+				return id
+			}
+			if base := strings.TrimSuffix(strings.TrimSuffix(id, "_day"), "_night"); l.symbols[base] {
+				return base
+			}
+			return "cloudy"
+		},
+		// dayGrid lays out the forecast days in the box at x, y of the
+		// given width and height.
+		"dayGrid": func(x, y, width, height float64) []DayCell {
+			var days []*Day
+			if data != nil {
+				days = data.Days
+			}
+			return dayGrid(days, x, y, width, height)
+		},
+		// hourlyChart lays out a chart of the next 24 hours in the box at
+		// x, y of the given width and height.
+		"hourlyChart": func(x, y, width, height float64) *HourlyChart {
+			return hourlyChart(f, now, units, locale, tz, x, y, width, height)
+		},
+	}
+}
+
+func sameDay(t time.Time, year int, month time.Month, day int) bool {
+	y, m, d := t.Date()
+	return y == year && m == month && d == day
+}
+
+// dayOrNight returns "day" or "night" for t at loc using the sunrise and
+// sunset nearest to t. When there is none within 12 hours, as during polar
+// day or night, the sun's elevation decides.
+func (f *Forecast) dayOrNight(loc Location, t time.Time) string {
+	var rise, set time.Time
+	best := 12 * time.Hour
+	nearest := func(r, s time.Time) {
+		if r.IsZero() || s.IsZero() {
+			return
+		}
+		distance := t.Sub(r.Add(s.Sub(r) / 2))
+		if distance < 0 {
+			distance = -distance
+		}
+		if distance < best {
+			best, rise, set = distance, r, s
+		}
+	}
+	nearest(f.Current.Sunrise, f.Current.Sunset)
+	for _, d := range f.Daily {
+		nearest(d.Sunrise, d.Sunset)
+	}
+	if rise.IsZero() {
+		if sunElevation(loc, t) > -0.833 {
+			return "day"
+		}
+		return "night"
+	}
+	return getDayOrNight(t, rise, set)
+}
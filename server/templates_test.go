@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLayoutOrientation checks that the built-in layouts match the shape of
+// the canvas, so a rotated device is filled rather than letterboxed.
+func TestLayoutOrientation(t *testing.T) {
+	srv, _ := newOpenMeteoStandIn(t)
+	f, err := newTestOpenMeteo(srv).Forecast(raleigh(t), UnitsImperial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layouts, err := loadLayouts("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name := range builtinLayouts {
+		for _, rotation := range []int{0, 90, 180, 270} {
+			dc := defaultConfig().Defaults
+			dc.Layout = name
+			dc.Rotation = &rotation
+			device, err := newDevice("test", dc, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var svg bytes.Buffer
+			if err := layouts[name].Execute(&svg, newTemplateData(f, nil, device, f.Current.Time.Add(time.Minute))); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			width, height, err := svgSize(svg.Bytes())
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			// The kindle3 canvas is the size of the layouts.
+			if cw, ch := device.Profile.Canvas(); width != float64(cw) || height != float64(ch) {
+				t.Errorf("%s rotated %d is %vx%v, want %dx%d", name, rotation, width, height, cw, ch)
+			}
+		}
+	}
+}
+
+// TestLayoutEscapesAlerts checks that the built-in layouts escape the texts
+// of alert feeds, which would otherwise break the SVG.
+func TestLayoutEscapesAlerts(t *testing.T) {
+	srv, _ := newOpenMeteoStandIn(t)
+	f, err := newTestOpenMeteo(srv).Forecast(raleigh(t), UnitsImperial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layouts, err := loadLayouts("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	alerts := []Alert{{Event: "Wind & <Rain>", Severity: "Moderate & rising"}}
+
+	for name := range builtinLayouts {
+		dc := defaultConfig().Defaults
+		dc.Layout = name
+		device, err := newDevice("test", dc, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var svg bytes.Buffer
+		if err := layouts[name].Execute(&svg, newTemplateData(f, alerts, device, f.Current.Time.Add(time.Minute))); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for d := xml.NewDecoder(bytes.NewReader(svg.Bytes())); ; {
+			if _, err := d.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+		if !bytes.Contains(svg.Bytes(), []byte("Wind &amp; &lt;Rain&gt;")) {
+			t.Errorf("%s does not escape the alert's event", name)
+		}
+	}
+}
+
+// TestLayoutPartials checks that layouts can include the shared partials,
+// with their symbols, and replace them.
+func TestLayoutPartials(t *testing.T) {
+	device, err := newDevice("test", defaultConfig().Defaults, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &Forecast{Source: "Stub", FetchedAt: time.Now(), Current: Current{Icon: "rain"}}
+	data := newTemplateData(f, nil, device, f.FetchedAt)
+
+	for _, tt := range []struct {
+		name, src, want string
+	}{
+		// without the symbols the icon falls back to cloudy
+		{"bare", `{{icon "rain"}}`, "cloudy"},
+		{"symbols", `{{icon "rain"}}{{if false}}{{template "defs"}}{{end}}`, "rain"},
+		{"footer", `{{template "footer" .}}`, "Powered by Stub"},
+		{"replaced", `{{define "footer"}}by {{.Forecast.Source}}{{end}}{{template "footer" .}}`, "by Stub"},
+	} {
+		l, err := parseLayout(tt.name, tt.src)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var out bytes.Buffer
+		if err := l.Execute(&out, data); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := out.String(); !strings.Contains(got, tt.want) || (tt.name == "replaced" && strings.Contains(got, "<g")) {
+			t.Errorf("%s: %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestDayGridPadding checks that days the provider did not return are left
+// as blank cells, so the grid keeps the device's number of days.
+func TestDayGridPadding(t *testing.T) {
+	srv, _ := newOpenMeteoStandIn(t)
+	full, err := newTestOpenMeteo(srv).Forecast(raleigh(t), UnitsImperial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// today and the next two days
+	short := *full
+	short.Daily = full.Daily[:3]
+	layouts, err := loadLayouts("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dc := defaultConfig().Defaults
+	dc.ForecastDays = 5
+	device, err := newDevice("test", dc, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := full.Current.Time.Add(time.Minute)
+
+	fullData, shortData := newTemplateData(full, nil, device, now), newTemplateData(&short, nil, device, now)
+	if len(shortData.Days) != 5 {
+		t.Fatalf("%d days, want 5", len(shortData.Days))
+	}
+	for i, d := range shortData.Days {
+		if (d == nil) != (i >= 2) {
+			t.Errorf("day %d = %v, want the first two days and then blanks", i, d)
+		}
+	}
+
+	want := dayGrid(fullData.Days, 0, 425, 600, 330)
+	cells := dayGrid(shortData.Days, 0, 425, 600, 330)
+	if len(cells) != len(want) {
+		t.Fatalf("%d cells, want %d", len(cells), len(want))
+	}
+	for i, c := range cells {
+		w := want[i]
+		if c.Day != shortData.Days[i] || c.X != w.X || c.Y != w.Y || c.Scale != w.Scale || c.Last != w.Last {
+			t.Errorf("cell %d = %+v, want %+v as with every day", i, c, w)
+		}
+	}
+
+	render := func(data *TemplateData) string {
+		var svg bytes.Buffer
+		if err := layouts["default"].Execute(&svg, data); err != nil {
+			t.Fatal(err)
+		}
+		return svg.String()
+	}
+	const weekday, divider = `font-size="30px" y="25" x="100"`, `<path d="m200,25,0,300,3,0,0-300-3,0z"/>`
+	fullSVG, shortSVG := render(fullData), render(shortData)
+	if n := strings.Count(fullSVG, weekday); n != 5 {
+		t.Fatalf("%d days drawn from the full forecast, want 5", n)
+	}
+	if n := strings.Count(shortSVG, weekday); n != 2 {
+		t.Errorf("%d days drawn, want 2", n)
+	}
+	if n, want := strings.Count(shortSVG, divider), strings.Count(fullSVG, divider); n != want {
+		t.Errorf("%d dividers, want %d as with every day", n, want)
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const defaultTomorrowURL = "https://api.tomorrow.io/v4/timelines"
+
+var tomorrowFields = "temperature,temperatureApparent,temperatureMax,temperatureMin,humidity,windSpeed,windDirection,pressureSurfaceLevel,precipitationIntensity,precipitationProbability,weatherCode,sunriseTime,sunsetTime,moonPhase,epaIndex,epaPrimaryPollutant,particulateMatter25,treeIndex,grassIndex,weedIndex"
+
+// TomorrowProvider implements WeatherProvider using the Tomorrow.io (formerly
+// ClimaCell) v4 timelines API.
+type TomorrowProvider struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+func NewTomorrowProvider(baseURL, apiKey string) *TomorrowProvider {
+	return &TomorrowProvider{
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		client:  newHTTPClient(),
+	}
+}
+
+func (p *TomorrowProvider) Name() string {
+	return "Tomorrow.io"
+}
+
+type tomorrowValues struct {
+	Temperature              *float64   `json:"temperature"`
+	TemperatureApparent      float64    `json:"temperatureApparent"`
+	TemperatureMax           *float64   `json:"temperatureMax"`
+	TemperatureMin           *float64   `json:"temperatureMin"`
+	Humidity                 float64    `json:"humidity"`
+	WindSpeed                float64    `json:"windSpeed"`
+	WindDirection            float64    `json:"windDirection"`
+	PressureSurfaceLevel     float64    `json:"pressureSurfaceLevel"`
+	PrecipitationIntensity   float64    `json:"precipitationIntensity"`
+	PrecipitationProbability float64    `json:"precipitationProbability"`
+	WeatherCode              int        `json:"weatherCode"`
+	SunriseTime              *time.Time `json:"sunriseTime"`
+	SunsetTime               *time.Time `json:"sunsetTime"`
+	MoonPhase                *int       `json:"moonPhase"`
+	EPAIndex                 *int       `json:"epaIndex"`
+	EPAPrimaryPollutant      *int       `json:"epaPrimaryPollutant"`
+	ParticulateMatter25      float64    `json:"particulateMatter25"`
+	TreeIndex                *int       `json:"treeIndex"`
+	GrassIndex               *int       `json:"grassIndex"`
+	WeedIndex                *int       `json:"weedIndex"`
+}
+
+type tomorrowInterval struct {
+	StartTime time.Time      `json:"startTime"`
+	Values    tomorrowValues `json:"values"`
+}
+
+type tomorrowResponse struct {
+	Data struct {
+		Timelines []struct {
+			Timestep  string             `json:"timestep"`
+			Intervals []tomorrowInterval `json:"intervals"`
+		} `json:"timelines"`
+	} `json:"data"`
+}
+
+func (p *TomorrowProvider) Forecast(loc Location, units string) (*Forecast, error) {
+	q := url.Values{}
+	q.Set("location", strconv.FormatFloat(loc.Lat, 'f', -1, 64)+","+strconv.FormatFloat(loc.Lon, 'f', -1, 64))
+	q.Set("fields", tomorrowFields)
+	q.Set("timesteps", "current,1h,1d")
+	q.Set("units", units)
+	q.Set("timezone", "auto")
+	q.Set("startTime", "now")
+	// today and the most days after it a device can show
+	q.Set("endTime", fmt.Sprintf("nowPlus%dd", maxForecastDays+1))
+	q.Set("apikey", p.apiKey)
+
+	logrus.Info("getting tomorrow.io timelines")
+	var resp tomorrowResponse
+	if err := getJSON(p.client, p.baseURL+"?"+q.Encode(), &resp); err != nil {
+		return nil, fmt.Errorf("error getting tomorrow.io timelines: %w", err)
+	}
+
+	forecast := &Forecast{
+		Source:    p.Name(),
+		FetchedAt: time.Now(),
+		Units:     units,
+	}
+	var current []tomorrowInterval
+	for _, timeline := range resp.Data.Timelines {
+		switch timeline.Timestep {
+		case "current":
+			current = timeline.Intervals
+		case "1h":
+			for _, i := range timeline.Intervals {
+				forecast.Hourly = append(forecast.Hourly, tomorrowHour(i))
+			}
+		case "1d":
+			for _, i := range timeline.Intervals {
+				forecast.Daily = append(forecast.Daily, tomorrowDay(i))
+			}
+		}
+	}
+	if len(current) == 0 || current[0].Values.Temperature == nil {
+		return nil, fmt.Errorf("tomorrow.io response has no current conditions")
+	}
+
+	v := current[0].Values
+	forecast.Current = Current{
+		Time:          current[0].StartTime,
+		Temp:          *v.Temperature,
+		FeelsLike:     v.TemperatureApparent,
+		Humidity:      v.Humidity,
+		WindSpeed:     v.WindSpeed,
+		WindDirection: v.WindDirection,
+		Pressure:      v.PressureSurfaceLevel,
+		Icon:          tomorrowIcon(v.WeatherCode),
+		MoonPhase:     moonPhaseAt(current[0].StartTime),
+	}
+	forecast.AirQuality, forecast.Pollen = tomorrowAirQuality(v)
+	if len(forecast.Daily) > 0 {
+		today := forecast.Daily[0]
+		forecast.Current.Sunrise = today.Sunrise
+		forecast.Current.Sunset = today.Sunset
+		forecast.Current.MoonPhase = today.MoonPhase
+	}
+	if units == UnitsMetric {
+		// Metric wind speeds are in m/s.
+		forecast.Current.WindSpeed *= 3.6
+		for i := range forecast.Hourly {
+			forecast.Hourly[i].WindSpeed *= 3.6
+		}
+	}
+	return forecast, nil
+}
+
+func tomorrowHour(i tomorrowInterval) Hour {
+	h := Hour{
+		Time:              i.StartTime,
+		Precipitation:     i.Values.PrecipitationIntensity,
+		PrecipProbability: i.Values.PrecipitationProbability,
+		WindSpeed:         i.Values.WindSpeed,
+		Icon:              tomorrowIcon(i.Values.WeatherCode),
+	}
+	if i.Values.Temperature != nil {
+		h.Temp = *i.Values.Temperature
+	}
+	return h
+}
+
+func tomorrowDay(i tomorrowInterval) Day {
+	v := i.Values
+	d := Day{
+		Date:          calendarDate(i.StartTime),
+		Precipitation: v.PrecipitationIntensity * 24,
+		Icon:          tomorrowIcon(v.WeatherCode),
+		MoonPhase:     moonPhaseAt(i.StartTime),
+	}
+	if v.TemperatureMax != nil {
+		d.High = *v.TemperatureMax
+	}
+	if v.TemperatureMin != nil {
+		d.Low = *v.TemperatureMin
+	}
+	if v.SunriseTime != nil {
+		d.Sunrise = *v.SunriseTime
+	}
+	if v.SunsetTime != nil {
+		d.Sunset = *v.SunsetTime
+	}
+	if v.MoonPhase != nil && *v.MoonPhase >= 0 && *v.MoonPhase < len(moonPhases) {
+		d.MoonPhase = moonPhases[*v.MoonPhase]
+	}
+	return d
+}
+
+// tomorrowAirQuality returns the air quality and pollen of v, each nil when
+// Tomorrow.io has no data for the location.
+func tomorrowAirQuality(v tomorrowValues) (*AirQuality, *Pollen) {
+	var aq *AirQuality
+	if v.EPAIndex != nil {
+		aq = &AirQuality{AQI: *v.EPAIndex, PM25: v.ParticulateMatter25}
+		if p := v.EPAPrimaryPollutant; p != nil && *p >= 0 && *p < len(pollutants) {
+			aq.PrimaryPollutant = pollutants[*p]
+		}
+	}
+	index := func(i *int) int {
+		if i == nil {
+			return 0
+		}
+		return *i
+	}
+	var pollen *Pollen
+	if v.TreeIndex != nil || v.GrassIndex != nil || v.WeedIndex != nil {
+		pollen = &Pollen{Tree: index(v.TreeIndex), Grass: index(v.GrassIndex), Weed: index(v.WeedIndex)}
+	}
+	return aq, pollen
+}
+
+var tomorrowIcons = map[int]string{
+	1000: "clear",
+	1100: "mostly_clear",
+	1101: "partly_cloudy",
+	1102: "mostly_cloudy",
+	1001: "cloudy",
+	2000: "fog",
+	2100: "fog_light",
+	4000: "drizzle",
+	4001: "rain",
+	4200: "rain_light",
+	4201: "rain_heavy",
+	5000: "snow",
+	5001: "flurries",
+	5100: "snow_light",
+	5101: "snow_heavy",
+	6000: "freezing_drizzle",
+	6001: "freezing_rain",
+	6200: "freezing_rain_light",
+	6201: "freezing_heavy_rain",
+	7000: "ice_pellets",
+	7101: "ice_pellets_heavy",
+	7102: "ice_pellets_light",
+	8000: "tstorm",
+}
+
+// tomorrowIcon maps a Tomorrow.io v4 weatherCode onto an icon id.
+func tomorrowIcon(code int) string {
+	if icon, ok := tomorrowIcons[code]; ok {
+		return icon
+	}
+	return "cloudy"
+}
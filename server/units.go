@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Unit systems. Providers are asked for imperial or metric data; the UK
+// system shows metric data with wind speeds in mph.
+const (
+	UnitsImperial = "imperial"
+	UnitsMetric   = "metric"
+	UnitsUK       = "uk"
+)
+
+// Wind speed units.
+const (
+	WindMph      = "mph"
+	WindKph      = "km/h"
+	WindMs       = "m/s"
+	WindKnots    = "knots"
+	WindBeaufort = "beaufort"
+)
+
+// Units are the units a device shows. Forecasts are in the imperial units
+// (°F, mph, inHg, in) or the metric units (°C, km/h, hPa, mm) named by
+// Forecast.Units and converted for display.
+type Units struct {
+	System        string
+	Celsius       bool
+	WindSpeed     string
+	Pressure      string
+	Precipitation string
+}
+
+var unitSystems = map[string]Units{
+	UnitsImperial: {System: UnitsImperial, WindSpeed: WindMph, Pressure: "inHg", Precipitation: "in"},
+	UnitsMetric:   {System: UnitsMetric, Celsius: true, WindSpeed: WindKph, Pressure: "hPa", Precipitation: "mm"},
+	UnitsUK:       {System: UnitsUK, Celsius: true, WindSpeed: WindMph, Pressure: "hPa", Precipitation: "mm"},
+}
+
+var windLabels = map[string]string{
+	WindMph:      "mph",
+	WindKph:      "km/h",
+	WindMs:       "m/s",
+	WindKnots:    "knots",
+	WindBeaufort: "Bft",
+}
+
+// lookupUnits returns the named unit system with its wind speed unit
+// replaced by wind, unless wind is empty.
+func lookupUnits(system, wind string) (Units, error) {
+	u, ok := unitSystems[strings.ToLower(system)]
+	if !ok {
+		return Units{}, fmt.Errorf("unknown unit system %q, must be imperial, metric or uk", system)
+	}
+	if wind != "" {
+		wind = strings.ToLower(wind)
+		if _, ok := windLabels[wind]; !ok {
+			return Units{}, fmt.Errorf("unknown wind speed unit %q, must be mph, km/h, m/s, knots or beaufort", wind)
+		}
+		u.WindSpeed = wind
+	}
+	return u, nil
+}
+
+// Base is the unit system forecasts are requested in.
+func (u Units) Base() string {
+	if u.System == UnitsImperial {
+		return UnitsImperial
+	}
+	return UnitsMetric
+}
+
+func (u Units) TemperatureLabel() string {
+	if u.Celsius {
+		return "°C"
+	}
+	return "°F"
+}
+
+func (u Units) WindSpeedLabel() string {
+	return windLabels[u.WindSpeed]
+}
+
+// Temperature converts a temperature in the from unit system.
+func (u Units) Temperature(v float64, from string) float64 {
+	switch {
+	case from == UnitsImperial && u.Celsius:
+		return fahrenheitToCelsius(v)
+	case from != UnitsImperial && !u.Celsius:
+		return celsiusToFahrenheit(v)
+	}
+	return v
+}
+
+// Wind converts a wind speed in the from unit system.
+func (u Units) Wind(v float64, from string) float64 {
+	kph := v
+	if from == UnitsImperial {
+		kph = mphToKph(v)
+	}
+	switch u.WindSpeed {
+	case WindMph:
+		return kphToMph(kph)
+	case WindMs:
+		return kph / 3.6
+	case WindKnots:
+		return kph / 1.852
+	case WindBeaufort:
+		return beaufort(kph / 3.6)
+	}
+	return kph
+}
+
+// PressureValue converts a pressure in the from unit system.
+func (u Units) PressureValue(v float64, from string) float64 {
+	switch {
+	case from == UnitsImperial && u.Pressure == "hPa":
+		return inHgToHPa(v)
+	case from != UnitsImperial && u.Pressure == "inHg":
+		return hPaToInHg(v)
+	}
+	return v
+}
+
+// PrecipitationValue converts a precipitation amount in the from unit system.
+func (u Units) PrecipitationValue(v float64, from string) float64 {
+	switch {
+	case from == UnitsImperial && u.Precipitation == "mm":
+		return v * 25.4
+	case from != UnitsImperial && u.Precipitation == "in":
+		return mmToInches(v)
+	}
+	return v
+}
+
+// FormatTemperature, FormatWind, FormatPressure and FormatPrecipitation
+// convert a value in the from unit system and format it for display.
+func (u Units) FormatTemperature(v float64, from string) string {
+	return strconv.FormatFloat(u.Temperature(v, from), 'f', 0, 64)
+}
+
+func (u Units) FormatWind(v float64, from string) string {
+	return strconv.FormatFloat(u.Wind(v, from), 'f', 0, 64)
+}
+
+func (u Units) FormatPressure(v float64, from string) string {
+	if u.Pressure == "inHg" {
+		return strconv.FormatFloat(u.PressureValue(v, from), 'f', 2, 64)
+	}
+	return strconv.FormatFloat(u.PressureValue(v, from), 'f', 0, 64)
+}
+
+func (u Units) FormatPrecipitation(v float64, from string) string {
+	if u.Precipitation == "in" {
+		return strconv.FormatFloat(u.PrecipitationValue(v, from), 'f', 2, 64)
+	}
+	return strconv.FormatFloat(u.PrecipitationValue(v, from), 'f', 1, 64)
+}
+
+// beaufortLimits are the upper wind speeds in m/s of Beaufort forces 0 to 11.
+var beaufortLimits = []float64{0.5, 1.6, 3.4, 5.5, 8.0, 10.8, 13.9, 17.2, 20.8, 24.5, 28.5, 32.7}
+
+func beaufort(ms float64) float64 {
+	for force, limit := range beaufortLimits {
+		if ms < limit {
+			return float64(force)
+		}
+	}
+	return 12
+}
+
+// convert converts the forecast's values into the given base unit system.
+func (f *Forecast) convert(units string) {
+	if f.Units == units {
+		return
+	}
+	u := unitSystems[units]
+	from := f.Units
+
+	c := &f.Current
+	c.Temp = u.Temperature(c.Temp, from)
+	c.FeelsLike = u.Temperature(c.FeelsLike, from)
+	c.WindSpeed = u.Wind(c.WindSpeed, from)
+	c.Pressure = u.PressureValue(c.Pressure, from)
+	for i := range f.Hourly {
+		h := &f.Hourly[i]
+		h.Temp = u.Temperature(h.Temp, from)
+		h.Precipitation = u.PrecipitationValue(h.Precipitation, from)
+		h.WindSpeed = u.Wind(h.WindSpeed, from)
+	}
+	for i := range f.Daily {
+		d := &f.Daily[i]
+		d.High = u.Temperature(d.High, from)
+		d.Low = u.Temperature(d.Low, from)
+		d.Precipitation = u.PrecipitationValue(d.Precipitation, from)
+	}
+	f.Units = units
+}
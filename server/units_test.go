@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestUnitConversions(t *testing.T) {
+	units := func(system, wind string) Units {
+		u, err := lookupUnits(system, wind)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+	imperial, metric, uk := units(UnitsImperial, ""), units(UnitsMetric, ""), units(UnitsUK, "")
+
+	for _, tt := range []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"°F to °C", metric.Temperature(212, UnitsImperial), 100},
+		{"°C to °F", imperial.Temperature(-40, UnitsMetric), -40},
+		{"°C in the UK", uk.Temperature(21.5, UnitsMetric), 21.5},
+		{"mph to km/h", metric.Wind(10, UnitsImperial), 16.09344},
+		{"km/h to mph", imperial.Wind(16.09344, UnitsMetric), 10},
+		{"km/h to mph in the UK", uk.Wind(16.09344, UnitsMetric), 10},
+		{"km/h to m/s", units(UnitsMetric, WindMs).Wind(36, UnitsMetric), 10},
+		{"mph to knots", units(UnitsImperial, WindKnots).Wind(11.507794, UnitsImperial), 10},
+		{"km/h to Beaufort", units(UnitsMetric, WindBeaufort).Wind(60, UnitsMetric), 7},
+		{"inHg to hPa", metric.PressureValue(29.92, UnitsImperial), 1013.2},
+		{"hPa to inHg", imperial.PressureValue(1013.25, UnitsMetric), 29.92},
+		{"hPa in the UK", uk.PressureValue(1013.25, UnitsMetric), 1013.25},
+		{"in to mm", metric.PrecipitationValue(0.5, UnitsImperial), 12.7},
+		{"mm to in", imperial.PrecipitationValue(25.4, UnitsMetric), 1},
+	} {
+		if math.Abs(tt.got-tt.want) > 0.01 {
+			t.Errorf("%s: %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	for _, tt := range []struct {
+		name, got, want string
+	}{
+		{"inHg", imperial.FormatPressure(1013.25, UnitsMetric), "29.92"},
+		{"hPa", metric.FormatPressure(29.92, UnitsImperial), "1013"},
+		{"in", imperial.FormatPrecipitation(3, UnitsMetric), "0.12"},
+		{"mm", metric.FormatPrecipitation(0.12, UnitsImperial), "3.0"},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("%s: %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestBeaufort(t *testing.T) {
+	for _, tt := range []struct {
+		ms    float64
+		force float64
+	}{
+		{0, 0}, {0.49, 0}, {0.5, 1}, {1.59, 1}, {1.6, 2},
+		{3.4, 3}, {5.5, 4}, {8, 5}, {10.8, 6}, {13.9, 7},
+		{17.2, 8}, {20.8, 9}, {24.5, 10}, {28.49, 10}, {28.5, 11},
+		{32.69, 11}, {32.7, 12}, {60, 12},
+	} {
+		if got := beaufort(tt.ms); got != tt.force {
+			t.Errorf("%v m/s is force %v, want %v", tt.ms, got, tt.force)
+		}
+	}
+}
+
+// TestLayoutUnits checks the pressure and precipitation functions of the
+// layouts in the device's units and locale.
+func TestLayoutUnits(t *testing.T) {
+	layout, err := parseLayout("test", `{{pressure .Forecast.Current.Pressure}} {{pressureUnit}}|{{precip 2.54}} {{precipUnit}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &Forecast{Units: UnitsMetric, FetchedAt: time.Now(), Current: Current{Pressure: 1013.25}}
+	for _, tt := range []struct {
+		units, locale, want string
+	}{
+		{"imperial", "en", "29.92 inHg|0.10 in"},
+		{"metric", "en", "1013 hPa|2.5 mm"},
+		{"uk", "de", "1013 hPa|2,5 mm"},
+	} {
+		dc := defaultConfig().Defaults
+		dc.Units, dc.Locale = tt.units, tt.locale
+		device, err := newDevice("test", dc, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		if err := layout.Execute(&out, newTemplateData(f, nil, device, f.FetchedAt)); err != nil {
+			t.Fatal(err)
+		}
+		if got := out.String(); got != tt.want {
+			t.Errorf("%s %s: %q, want %q", tt.units, tt.locale, got, tt.want)
+		}
+	}
+}